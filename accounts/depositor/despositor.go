@@ -13,12 +13,15 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/accounts/abi/bind"
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/evrclient"
 	"github.com/Evrynetlabs/evrynet-node/params"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/evrynet-official/evrynet-tools/accounts"
+	"github.com/evrynet-official/evrynet-tools/lib/metrics"
+	"github.com/evrynet-official/evrynet-tools/lib/node"
 )
 
 var (
@@ -29,6 +32,9 @@ var (
 
 const (
 	txPerturn = 20
+	// receiptCheckInterval is how often the receipt of a tx is checked when no new block comes,
+	// so that the error of a node going down is returned
+	receiptCheckInterval = 5 * time.Second
 )
 
 // ClientInterface
@@ -53,6 +59,7 @@ type Depositor struct {
 	expectBalance       *big.Int
 	numWorkers          int
 	nCoreAccount        int
+	signer              types.Signer
 }
 
 //Option provide initial behaviour of Depositor
@@ -86,6 +93,13 @@ func WithNumWorkers(numWorkers int) Option {
 	}
 }
 
+// WithSigner return an Option to set the signer of the txs sent by depositor
+func WithSigner(signer types.Signer) Option {
+	return func(dp *Depositor) {
+		dp.signer = signer
+	}
+}
+
 //NewDepositor returns a depositor
 func NewDepositor(sugar *zap.SugaredLogger, opt *bind.TransactOpts, address common.Address, walletAddrs []*accounts.Account, ethClient ClientInterface, exp *big.Int, ncore int, opts ...Option) *Depositor {
 	depositor := &Depositor{
@@ -98,6 +112,7 @@ func NewDepositor(sugar *zap.SugaredLogger, opt *bind.TransactOpts, address comm
 		expectBalance:       exp,
 		checkMiningInterval: checkMiningInterval,
 		nCoreAccount:        ncore,
+		signer:              types.HomesteadSigner{},
 	}
 	for _, opt := range opts {
 		opt(depositor)
@@ -116,19 +131,55 @@ func (dp *Depositor) sendEvrFromDepositor(to common.Address, amount *big.Int, no
 		return common.Hash{}, err
 	}
 	tx := types.NewTransaction(nonce, to, amount, dp.gasLimit, gasPrice, nil)
-	signedTx, err := dp.opt.Signer(types.HomesteadSigner{}, dp.opt.From, tx)
+	signedTx, err := dp.opt.Signer(dp.signer, dp.opt.From, tx)
 	if err != nil {
 		return common.Hash{}, err
 	}
 
 	if err = dp.client.SendTransaction(context.Background(), signedTx); err != nil {
+		metrics.TxFailed(err)
 		return common.Hash{}, err
 	}
+	metrics.TxsSent.Inc()
+	metrics.TxsInFlight.Inc()
 	dp.sendEthHook()
 	return signedTx.Hash(), nil
 }
 
+// PrintClientStats prints the counters of every endpoint when the client spreads requests over several nodes
+func (dp *Depositor) PrintClientStats() {
+	if pool, ok := dp.client.(*node.Pool); ok {
+		pool.PrintStats()
+	}
+}
+
+// subscribeNewHeads sends the header of every new block to ch, it returns nil when the client can not watch blocks
+func (dp *Depositor) subscribeNewHeads(ch chan<- *types.Header) evrynet.Subscription {
+	switch client := dp.client.(type) {
+	case *node.Pool:
+		return client.SubscribeNewHeads(dp.checkMiningInterval, ch)
+	case *evrclient.Client:
+		return node.SubscribeNewHeads(client, dp.checkMiningInterval, ch)
+	}
+	return nil
+}
+
+// waitForTx returns the receipt of a tx, checking for it at every new block and every receiptCheckInterval,
+// or every checkMiningInterval when the client can not watch blocks. The tx is no longer counted in flight
+// once the wait ends, mined or not
 func (dp *Depositor) waitForTx(hash common.Hash) (*types.Receipt, error) {
+	defer metrics.TxsInFlight.Dec()
+	var (
+		heads    = make(chan *types.Header)
+		subErr   <-chan error
+		interval = receiptCheckInterval
+	)
+	if sub := dp.subscribeNewHeads(heads); sub != nil {
+		defer sub.Unsubscribe()
+		subErr = sub.Err()
+	} else {
+		heads, interval = nil, dp.checkMiningInterval
+	}
 	for {
 		receipt, err := dp.client.TransactionReceipt(context.Background(), hash)
 		switch err {
@@ -143,7 +194,16 @@ func (dp *Depositor) waitForTx(hash common.Hash) (*types.Receipt, error) {
 		default:
 			return receipt, err
 		}
-		time.Sleep(dp.checkMiningInterval)
+		select {
+		case <-heads:
+		case <-time.After(interval):
+		case err := <-subErr:
+			if err != nil {
+				return nil, err
+			}
+			// no more heads, the receipt is still checked every interval
+			subErr, heads = nil, nil
+		}
 	}
 }
 
@@ -205,15 +265,17 @@ func (dp *Depositor) sendEvr(acc *accounts.Account, to *accounts.Account, nonce
 		err error
 	)
 	transaction := types.NewTransaction(nonce.Uint64(), to.Address, dp.expectBalance, estGas, gasPrice, nil)
-	transaction, err = types.SignTx(transaction, types.HomesteadSigner{}, acc.PriKey)
+	transaction, err = types.SignTx(transaction, dp.signer, acc.PriKey)
 	if err != nil {
 		return err
 	}
 
 	err = dp.client.SendTransaction(context.Background(), transaction)
 	if err != nil {
+		metrics.TxFailed(err)
 		return errors.Wrapf(err, "failed to send %d EVR from %s nonce %s", dp.expectBalance, acc.Address.Hex(), nonce.String())
 	}
+	metrics.TxsSent.Inc()
 	fmt.Printf("Sent %d EVR from %s => %s nonce %s \n", dp.expectBalance, acc.Address.Hex(), to.Address.Hex(), nonce.String())
 	nonce = nonce.Add(nonce, common.Big1)
 	return nil
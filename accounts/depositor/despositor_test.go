@@ -2,6 +2,7 @@ package depositor
 
 import (
 	"context"
+	"errors"
 	"math/big"
 	"testing"
 
@@ -11,8 +12,12 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/core"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/crypto"
+
+	"github.com/evrynet-official/evrynet-tools/accounts"
 	zapLog "github.com/evrynet-official/evrynet-tools/lib/log"
+	"github.com/evrynet-official/evrynet-tools/lib/metrics"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -27,6 +32,15 @@ const (
 	testGasLimit = 100000000
 )
 
+// simulatedClient suggests the gas price the simulated chain config requires instead of 1 wei.
+type simulatedClient struct {
+	*backends.SimulatedBackend
+}
+
+func (simulatedClient) SuggestGasPrice(context.Context) (*big.Int, error) {
+	return gasPrice, nil
+}
+
 func TestDepositor(t *testing.T) {
 	pk, err := crypto.HexToECDSA(NodePk)
 	assert.NoError(t, err)
@@ -49,14 +63,18 @@ func TestDepositor(t *testing.T) {
 				Balance: big.NewInt(testBal2),
 			},
 			wAddrs[2]: core.GenesisAccount{
-				Balance: big.NewInt(testBal2 * 100000),
+				Balance: new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil),
 			},
 		}
 	)
 
 	zapLogger, _, err := zapLog.NewSugaredLogger(nil)
 	sim := backends.NewSimulatedBackend(genAlloc, testGasLimit)
-	dep := NewDepositor(zapLogger, opt, wAddrs, sim, big.NewInt(testExpBal),
+	var accs []*accounts.Account
+	for _, addr := range wAddrs {
+		accs = append(accs, &accounts.Account{Address: addr})
+	}
+	dep := NewDepositor(zapLogger, opt, opt.From, accs, simulatedClient{sim}, big.NewInt(testExpBal), len(accs),
 		WithSendETHHook(sim.Commit),
 		WithCheckMiningInterval(0),
 		WithGasLimit(GasLimit),
@@ -64,5 +82,31 @@ func TestDepositor(t *testing.T) {
 	assert.NoError(t, dep.CheckAndDeposit())
 	newBalance, err := dep.client.BalanceAt(context.Background(), wAddrs[0], nil)
 	assert.NoError(t, err)
-	assert.Equal(t, int64(testExpBal), newBalance.Int64())
+	// a core account receives the expected balance plus the fee of the txs it has to send
+	txsCost := new(big.Int).Mul(big.NewInt(int64(estGas)), gasPrice)
+	assert.Equal(t, new(big.Int).Add(big.NewInt(testBal1+testExpBal), txsCost).String(), newBalance.String())
+}
+
+// brokenClient fails to return receipts, like a node going down
+type brokenClient struct {
+	simulatedClient
+}
+
+func (brokenClient) TransactionReceipt(context.Context, common.Hash) (*types.Receipt, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestDepositor_waitForTx(t *testing.T) {
+	zapLogger, _, err := zapLog.NewSugaredLogger(nil)
+	assert.NoError(t, err)
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{}, testGasLimit)
+	dep := NewDepositor(zapLogger, nil, common.Address{}, nil, brokenClient{simulatedClient{sim}}, big.NewInt(testExpBal), 1,
+		WithCheckMiningInterval(0),
+	)
+	inFlight := testutil.ToFloat64(metrics.TxsInFlight)
+	metrics.TxsInFlight.Inc()
+	_, err = dep.waitForTx(common.Hash{})
+	assert.Error(t, err)
+	// a failed wait no longer counts the tx in flight
+	assert.Equal(t, inFlight, testutil.ToFloat64(metrics.TxsInFlight))
 }
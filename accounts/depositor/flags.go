@@ -40,7 +40,8 @@ var (
 
 // NewDepositFlags return flags to create a depositor
 func NewDepositFlags() []cli.Flag {
-	return []cli.Flag{accounts.NumAccountsFlag, accounts.SeedFlag, senderPkFlag, expectedBalanceFlag, numberOfWorkerFlag, numberOfCoreFlag}
+	flags := []cli.Flag{accounts.NumAccountsFlag, accounts.SeedFlag, senderPkFlag, expectedBalanceFlag, numberOfWorkerFlag, numberOfCoreFlag}
+	return append(flags, node.NewSignerFlags()...)
 }
 
 // NewDepositFlags return a ready-to-use depositor from cli
@@ -77,13 +78,17 @@ func NewDepositorFromFlag(ctx *cli.Context, logger *zap.SugaredLogger) (*Deposit
 		return types.SignTx(tx, signer, pk)
 	}
 
-	evrClient, err := node.NewEvrynetClientFromFlags(ctx)
+	evrClient, err := node.NewPoolFromFlags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := node.SignerFromFlags(ctx, evrClient)
 	if err != nil {
 		return nil, err
 	}
 
 	dep := NewDepositor(logger, opt, crypto.PubkeyToAddress(pk.PublicKey), accs, evrClient, expectedAmount, nCore,
-		WithGasLimit(gasLimit), WithNumWorkers(nworker),
+		WithGasLimit(gasLimit), WithNumWorkers(nworker), WithSigner(signer),
 	)
 	return dep, nil
 
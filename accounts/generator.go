@@ -13,8 +13,14 @@ import (
 )
 
 func GenerateAccounts(num int, seed string) ([]*Account, error) {
+	return GenerateAccountsFrom(0, num, seed)
+}
+
+// GenerateAccountsFrom generates num accounts starting at index from, so that
+// GenerateAccountsFrom(from, num, seed) equals GenerateAccounts(from+num, seed)[from:].
+func GenerateAccountsFrom(from, num int, seed string) ([]*Account, error) {
 	var accs []*Account
-	for i := 0; i < num; i++ {
+	for i := from; i < from+num; i++ {
 		seedBytes := []byte(seed + strconv.Itoa(i))
 		seedBytes = append(seedBytes, bytes.Repeat([]byte{0x00}, ed25519.SeedSize-len(seedBytes))...)
 
@@ -89,3 +89,14 @@ func TestGenerateAccount(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateAccountsFrom(t *testing.T) {
+	all, err := GenerateAccounts(5, "test_1")
+	assert.NoError(t, err)
+	part, err := GenerateAccountsFrom(2, 3, "test_1")
+	assert.NoError(t, err)
+	assert.Len(t, part, 3)
+	for i, acc := range part {
+		assert.Equal(t, all[i+2].Address, acc.Address)
+	}
+}
@@ -4,11 +4,15 @@ import (
 	"context"
 	"errors"
 	"math/big"
+	"sync"
 	"time"
 
+	"github.com/Evrynetlabs/evrynet-node"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/evrclient"
 	"github.com/urfave/cli"
 
+	"github.com/evrynet-official/evrynet-tools/lib/metrics"
 	"github.com/evrynet-official/evrynet-tools/lib/node"
 )
 
@@ -27,29 +31,94 @@ func NewBlcClientFlag() []cli.Flag {
 
 type Blockchain struct {
 	Client      *evrclient.Client
+	Endpoint    string
 	LatestBlock *big.Int
 	Duration    time.Duration
+
+	mu     *sync.Mutex
+	head   *big.Int
+	headAt time.Time
 }
 
+// maxHeadAge is the age after which a watched head is checked with the node, as polling does not end
+// the subscription of an unreachable node
+const maxHeadAge = 30 * time.Second
+
 func NewBlcClientFromFlags(ctx *cli.Context) (*Blockchain, error) {
 	var (
 		delay = ctx.Duration(timeTickerFlag.Name)
 	)
 
-	client, err := node.NewEvrynetClientFromFlags(ctx)
+	return NewBlockchain(node.EndpointFromFlags(ctx), delay)
+}
+
+// NewBlockchain connects to the node of endpoint
+func NewBlockchain(endpoint string, delay time.Duration) (*Blockchain, error) {
+	client, err := evrclient.Dial(endpoint)
 	if err != nil {
 		return nil, err
 	}
 	blcClient := &Blockchain{
 		Client:      client,
+		Endpoint:    endpoint,
 		LatestBlock: new(big.Int).SetUint64(0),
 		Duration:    delay,
+		mu:          &sync.Mutex{},
 	}
 	return blcClient, nil
 }
 
+// WatchHeads keeps track of the latest block pushed by the node, or polled when the node cannot push.
+// Once it is called GetLastBlock returns the latest watched block instead of asking the node, unless the block
+// was watched more than maxHeadAge ago or the subscription failed.
+func (blc *Blockchain) WatchHeads() evrynet.Subscription {
+	var (
+		heads = make(chan *types.Header)
+		sub   = node.SubscribeNewHeads(blc.Client, node.DefaultPollInterval, heads)
+	)
+	go func() {
+		for {
+			select {
+			case head := <-heads:
+				blc.mu.Lock()
+				blc.head = head.Number
+				blc.headAt = time.Now()
+				blc.mu.Unlock()
+				metrics.LatestBlock.Set(float64(head.Number.Uint64()))
+			case <-sub.Err():
+				// the node is asked again so that GetLastBlock reports when it is unreachable
+				blc.mu.Lock()
+				blc.head = nil
+				blc.mu.Unlock()
+				return
+			}
+		}
+	}()
+	return sub
+}
+
 func (blc *Blockchain) GetLastBlock() (*big.Int, error) {
+	blc.mu.Lock()
+	head := blc.head
+	fresh := time.Since(blc.headAt) < maxHeadAge
+	blc.mu.Unlock()
+	if head != nil && fresh {
+		return new(big.Int).Set(head), nil
+	}
+
+	number, err := blc.Head()
+	if err != nil {
+		return nil, err
+	}
+	metrics.LatestBlock.Set(float64(number.Uint64()))
+	return number, nil
+}
+
+// Head asks the node for the number of its latest block
+func (blc *Blockchain) Head() (*big.Int, error) {
+	start := time.Now()
 	header, err := blc.Client.HeaderByNumber(context.Background(), nil)
+	metrics.ObserveRPC(blc.Endpoint, "eth_getBlockByNumber", time.Since(start))
 	if err != nil {
 		return nil, err
 	}
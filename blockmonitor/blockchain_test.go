@@ -0,0 +1,26 @@
+package blockmonitor
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockchain_GetLastBlock(t *testing.T) {
+	// nothing listens on port 1
+	blc, err := NewBlockchain("http://127.0.0.1:1", time.Millisecond)
+	require.NoError(t, err)
+
+	blc.head, blc.headAt = big.NewInt(5), time.Now()
+	head, err := blc.GetLastBlock()
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), head.Int64())
+
+	// an old watched head is checked with the node
+	blc.headAt = time.Now().Add(-maxHeadAge)
+	_, err = blc.GetLastBlock()
+	assert.Error(t, err)
+}
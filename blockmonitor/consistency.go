@@ -0,0 +1,357 @@
+package blockmonitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+
+	"github.com/evrynet-official/evrynet-tools/lib/metrics"
+	"github.com/evrynet-official/evrynet-tools/lib/node"
+)
+
+var (
+	depthFlag = cli.Uint64Flag{
+		Name:  "depth",
+		Usage: "Number of the latest heights every node has that are compared",
+		Value: 10,
+	}
+	maxLagFlag = cli.Uint64Flag{
+		Name:  "max-lag",
+		Usage: "Number of blocks a node may be behind the highest node before it is reported as lagging",
+		Value: 5,
+	}
+	continuousFlag = cli.BoolFlag{
+		Name:  "continuous",
+		Usage: "Check the nodes every --interval until interrupted instead of once",
+	}
+	intervalFlag = cli.DurationFlag{
+		Name:  "interval",
+		Usage: "Time between two checks of --continuous",
+		Value: 10 * time.Second,
+	}
+)
+
+// NewConsistencyFlags returns flags to check that nodes agree
+func NewConsistencyFlags() []cli.Flag {
+	return []cli.Flag{depthFlag, maxLagFlag, continuousFlag, intervalFlag}
+}
+
+// Consistency compares the chains of several nodes
+type Consistency struct {
+	Nodes []*Blockchain
+	// Depth is the number of the latest common heights compared
+	Depth uint64
+	// MaxLag is the number of blocks a node may be behind the highest node
+	MaxLag     uint64
+	Continuous bool
+	Interval   time.Duration
+	// Notifier is alerted when the nodes fork and when they agree again, if it is set
+	Notifier Notifier
+
+	forked bool
+	// counted is whether the nodes were forked at the last check, when the fork was counted
+	counted bool
+}
+
+// NewConsistencyFromFlags returns a check of every RPC endpoint of flags
+func NewConsistencyFromFlags(ctx *cli.Context) (*Consistency, error) {
+	c := &Consistency{
+		Depth:      ctx.Uint64(depthFlag.Name),
+		MaxLag:     ctx.Uint64(maxLagFlag.Name),
+		Continuous: ctx.Bool(continuousFlag.Name),
+		Interval:   ctx.Duration(intervalFlag.Name),
+	}
+	for _, endpoint := range node.EndpointsFromFlags(ctx) {
+		blc, err := NewBlockchain(endpoint, c.Interval)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can not connect to %s", endpoint)
+		}
+		c.Nodes = append(c.Nodes, blc)
+	}
+	if len(c.Nodes) < 2 {
+		return nil, errors.New("at least 2 nodes are needed, repeat --rpcendpoint")
+	}
+	notifiers, err := NewNotifiersFromFlags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(notifiers) != 0 {
+		c.Notifier = notifiers
+	}
+	return c, nil
+}
+
+// NodeStatus is the head of a node and how far it is behind the highest node
+type NodeStatus struct {
+	Endpoint string
+	Head     uint64
+	Lag      uint64
+	// Err is why the node could not be checked, nil when it was
+	Err error
+}
+
+// BlockView is a block at a height as seen by a node
+type BlockView struct {
+	Endpoint string
+	Hash     common.Hash
+	Txs      uint
+}
+
+// Divergence is a height at which the nodes do not agree, on the hash of the block for a fork,
+// or on its number of txs
+type Divergence struct {
+	Number uint64
+	Fork   bool
+	Views  []BlockView
+}
+
+// Report is the result of a check of the nodes
+type Report struct {
+	Time    time.Time
+	Nodes   []NodeStatus
+	Highest uint64
+	// Common is the highest height every reachable node has
+	Common      uint64
+	Divergences []Divergence
+}
+
+// Forks returns the heights at which nodes have different blocks
+func (r *Report) Forks() []Divergence {
+	var forks []Divergence
+	for _, d := range r.Divergences {
+		if d.Fork {
+			forks = append(forks, d)
+		}
+	}
+	return forks
+}
+
+// Lagging returns the nodes more than maxLag blocks behind the highest node, or not reachable
+func (r *Report) Lagging(maxLag uint64) []NodeStatus {
+	var lagging []NodeStatus
+	for _, status := range r.Nodes {
+		if status.Err != nil || status.Lag > maxLag {
+			lagging = append(lagging, status)
+		}
+	}
+	return lagging
+}
+
+// Check compares the heads of the nodes, and the blocks of the latest Depth heights every node has
+func (c *Consistency) Check() *Report {
+	report := &Report{Time: time.Now(), Nodes: make([]NodeStatus, len(c.Nodes))}
+	forEach(c.Nodes, func(i int, blc *Blockchain) {
+		report.Nodes[i].Endpoint = blc.Endpoint
+		// the latest block gauge is the one of the monitored node, the heads of the nodes are their lag
+		head, err := blc.Head()
+		if err != nil {
+			report.Nodes[i].Err = err
+			return
+		}
+		report.Nodes[i].Head = head.Uint64()
+	})
+
+	var reachable []*Blockchain
+	for i, status := range report.Nodes {
+		if status.Err != nil {
+			continue
+		}
+		if len(reachable) == 0 || status.Head < report.Common {
+			report.Common = status.Head
+		}
+		if status.Head > report.Highest {
+			report.Highest = status.Head
+		}
+		reachable = append(reachable, c.Nodes[i])
+	}
+	for i := range report.Nodes {
+		if report.Nodes[i].Err == nil {
+			report.Nodes[i].Lag = report.Highest - report.Nodes[i].Head
+			metrics.NodeLag.WithLabelValues(report.Nodes[i].Endpoint).Set(float64(report.Nodes[i].Lag))
+		}
+	}
+	if len(reachable) < 2 {
+		return report
+	}
+
+	for n := uint64(0); n < c.Depth && n <= report.Common; n++ {
+		number := report.Common - n
+		views := make([]BlockView, len(reachable))
+		errs := make([]error, len(reachable))
+		forEach(reachable, func(i int, blc *Blockchain) {
+			views[i].Endpoint = blc.Endpoint
+			header, err := blc.Header(number)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			views[i].Hash = header.Hash()
+			views[i].Txs, errs[i] = blc.TxCount(views[i].Hash)
+		})
+		if d, ok := compare(number, views, errs); ok {
+			report.Divergences = append(report.Divergences, d)
+		}
+	}
+	// a fork is counted once, when the nodes fork after agreeing
+	forked := len(report.Forks()) != 0
+	if forked && !c.counted {
+		metrics.Forks.Inc()
+	}
+	c.counted = forked
+	return report
+}
+
+// compare returns the divergence of the views of a height, the views of nodes that failed are left out
+func compare(number uint64, views []BlockView, errs []error) (Divergence, bool) {
+	d := Divergence{Number: number}
+	for i, view := range views {
+		if errs[i] == nil {
+			d.Views = append(d.Views, view)
+		}
+	}
+	if len(d.Views) < 2 {
+		return d, false
+	}
+	var diverged bool
+	for _, view := range d.Views[1:] {
+		if view.Hash != d.Views[0].Hash {
+			d.Fork = true
+		}
+		if view.Hash != d.Views[0].Hash || view.Txs != d.Views[0].Txs {
+			diverged = true
+		}
+	}
+	return d, diverged
+}
+
+// forEach calls fn for every node at the same time
+func forEach(nodes []*Blockchain, fn func(i int, blc *Blockchain)) {
+	var wg sync.WaitGroup
+	for i, blc := range nodes {
+		wg.Add(1)
+		go func(i int, blc *Blockchain) {
+			defer wg.Done()
+			fn(i, blc)
+		}(i, blc)
+	}
+	wg.Wait()
+}
+
+// Header returns the header of a block
+func (blc *Blockchain) Header(number uint64) (*types.Header, error) {
+	start := time.Now()
+	header, err := blc.Client.HeaderByNumber(context.Background(), new(big.Int).SetUint64(number))
+	metrics.ObserveRPC(blc.Endpoint, "eth_getBlockByNumber", time.Since(start))
+	if err != nil {
+		return nil, errors.Wrapf(err, "can not get block %d", number)
+	}
+	return header, nil
+}
+
+// TxCount returns the number of txs of a block
+func (blc *Blockchain) TxCount(hash common.Hash) (uint, error) {
+	start := time.Now()
+	count, err := blc.Client.TransactionCount(context.Background(), hash)
+	metrics.ObserveRPC(blc.Endpoint, "eth_getBlockTransactionCountByHash", time.Since(start))
+	if err != nil {
+		return 0, errors.Wrapf(err, "can not get tx count of block %s", hash.Hex())
+	}
+	return count, nil
+}
+
+// Print prints the report on console view
+func (r *Report) Print(maxLag uint64) {
+	fmt.Printf("-----------Consistency Stats %s----------------\n", r.Time.Format(time.RFC3339))
+	fmt.Printf("Highest block: %d, highest common block: %d\n", r.Highest, r.Common)
+	fmt.Printf("%-40s %10s %8s\n", "Node", "Head", "Lag")
+	nodes := make([]NodeStatus, len(r.Nodes))
+	copy(nodes, r.Nodes)
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].Lag < nodes[j].Lag })
+	for _, status := range nodes {
+		switch {
+		case status.Err != nil:
+			fmt.Printf("%-40s %10s %8s UNREACHABLE: %s\n", status.Endpoint, "-", "-", status.Err)
+		case status.Lag > maxLag:
+			fmt.Printf("%-40s %10d %8d LAGGING\n", status.Endpoint, status.Head, status.Lag)
+		default:
+			fmt.Printf("%-40s %10d %8d\n", status.Endpoint, status.Head, status.Lag)
+		}
+	}
+	if lagging := r.Lagging(maxLag); len(lagging) != 0 {
+		fmt.Printf("Nodes lagging or unreachable: %d of %d\n", len(lagging), len(r.Nodes))
+	}
+	if len(r.Divergences) == 0 {
+		fmt.Println("Every node agrees on the compared blocks")
+		return
+	}
+	for _, d := range r.Divergences {
+		kind := "TX COUNT MISMATCH"
+		if d.Fork {
+			kind = "FORK"
+		}
+		var views []string
+		for _, view := range d.Views {
+			views = append(views, fmt.Sprintf("%s: %s (%d txs)", view.Endpoint, view.Hash.Hex(), view.Txs))
+		}
+		fmt.Printf("%s at block %d\n  %s\n", kind, d.Number, strings.Join(views, "\n  "))
+	}
+}
+
+// Run checks the nodes once, or every Interval until stop is closed when Continuous is set. It returns an error
+// when the last check found a fork.
+func (c *Consistency) Run(stop <-chan struct{}) error {
+	report := c.Check()
+	report.Print(c.MaxLag)
+	c.alert(report)
+	if c.Continuous {
+		ticker := time.NewTicker(c.Interval)
+		defer ticker.Stop()
+	loop:
+		for {
+			select {
+			case <-ticker.C:
+				report = c.Check()
+				report.Print(c.MaxLag)
+				c.alert(report)
+			case <-stop:
+				break loop
+			}
+		}
+	}
+	if forks := report.Forks(); len(forks) != 0 {
+		return fmt.Errorf("nodes are forked from block %d", forks[len(forks)-1].Number)
+	}
+	return nil
+}
+
+// alert notifies when the nodes of report fork, or agree again after a fork
+func (c *Consistency) alert(report *Report) {
+	forks := report.Forks()
+	if c.Notifier == nil || (len(forks) != 0) == c.forked {
+		return
+	}
+	c.forked = len(forks) != 0
+	var (
+		caption = "OK"
+		msg     = fmt.Sprintf("[%s] Nodes agree again up to block %d", report.Time.Format(time.RFC3339), report.Common)
+	)
+	if c.forked {
+		caption = "FORK"
+		msg = fmt.Sprintf("[%s] Nodes are forked from block %d", report.Time.Format(time.RFC3339), forks[len(forks)-1].Number)
+	}
+	if err := c.Notifier.Notify(msg, caption); err != nil {
+		log.Printf("can not send alert %s", err.Error())
+	}
+	metrics.Alerts.WithLabelValues(caption).Inc()
+}
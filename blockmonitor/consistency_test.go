@@ -0,0 +1,152 @@
+package blockmonitor
+
+import (
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/rpc"
+
+	"github.com/evrynet-official/evrynet-tools/lib/metrics"
+)
+
+// testChain serves the headers and tx counts of a chain
+type testChain struct {
+	headers []*types.Header
+	txs     map[common.Hash]uint
+}
+
+// newTestChain returns a chain of n blocks, the blocks from forkAt have other hashes than the ones of other chains
+func newTestChain(n, forkAt int) *testChain {
+	chain := &testChain{txs: make(map[common.Hash]uint)}
+	for i := 0; i < n; i++ {
+		header := &types.Header{Number: big.NewInt(int64(i)), Difficulty: big.NewInt(1), Time: uint64(i)}
+		if forkAt >= 0 && i >= forkAt {
+			header.Extra = []byte("fork")
+		}
+		chain.headers = append(chain.headers, header)
+		chain.txs[header.Hash()] = uint(i)
+	}
+	return chain
+}
+
+func (c *testChain) GetBlockByNumber(number rpc.BlockNumber, _ bool) (*types.Header, error) {
+	if number == rpc.LatestBlockNumber {
+		return c.headers[len(c.headers)-1], nil
+	}
+	if int(number) >= len(c.headers) {
+		return nil, nil
+	}
+	return c.headers[number], nil
+}
+
+func (c *testChain) GetBlockTransactionCountByHash(hash common.Hash) *hexutil.Uint {
+	count, ok := c.txs[hash]
+	if !ok {
+		return nil
+	}
+	return (*hexutil.Uint)(&count)
+}
+
+func newTestConsistency(t *testing.T, chains ...*testChain) (*Consistency, func()) {
+	c := &Consistency{Depth: 3, MaxLag: 1, Interval: time.Millisecond}
+	var servers []*httptest.Server
+	for _, chain := range chains {
+		server := rpc.NewServer()
+		require.NoError(t, server.RegisterName("eth", chain))
+		httpServer := httptest.NewServer(server)
+		servers = append(servers, httpServer)
+		blc, err := NewBlockchain(httpServer.URL, c.Interval)
+		require.NoError(t, err)
+		c.Nodes = append(c.Nodes, blc)
+	}
+	return c, func() {
+		for _, server := range servers {
+			server.Close()
+		}
+	}
+}
+
+func TestConsistency_Check(t *testing.T) {
+	c, done := newTestConsistency(t, newTestChain(10, -1), newTestChain(8, -1), newTestChain(10, -1))
+	defer done()
+
+	report := c.Check()
+	assert.Equal(t, uint64(9), report.Highest)
+	assert.Equal(t, uint64(7), report.Common)
+	assert.Equal(t, uint64(0), report.Nodes[0].Lag)
+	assert.Equal(t, uint64(2), report.Nodes[1].Lag)
+	assert.Empty(t, report.Divergences)
+	require.Len(t, report.Lagging(c.MaxLag), 1)
+	assert.Equal(t, c.Nodes[1].Endpoint, report.Lagging(c.MaxLag)[0].Endpoint)
+	assert.NoError(t, c.Run(nil))
+}
+
+func TestConsistency_CheckFork(t *testing.T) {
+	c, done := newTestConsistency(t, newTestChain(10, -1), newTestChain(10, 8))
+	defer done()
+
+	report := c.Check()
+	forks := report.Forks()
+	// the compared heights are 9, 8 and 7
+	require.Len(t, forks, 2)
+	assert.Equal(t, uint64(9), forks[0].Number)
+	assert.Equal(t, uint64(8), forks[1].Number)
+	assert.NotEqual(t, forks[1].Views[0].Hash, forks[1].Views[1].Hash)
+
+	stop := make(chan struct{})
+	close(stop)
+	c.Continuous = true
+	assert.Error(t, c.Run(stop))
+
+	// the fork is counted once while it lasts, the heads of the nodes do not set the latest block gauge
+	counted := testutil.ToFloat64(metrics.Forks)
+	metrics.LatestBlock.Set(-1)
+	c.Check()
+	c.Check()
+	assert.Equal(t, counted, testutil.ToFloat64(metrics.Forks))
+	assert.Equal(t, float64(-1), testutil.ToFloat64(metrics.LatestBlock))
+}
+
+func TestConsistency_CheckUnreachable(t *testing.T) {
+	c, done := newTestConsistency(t, newTestChain(10, -1), newTestChain(10, -1))
+	defer done()
+	// nothing listens on port 1
+	unreachable, err := NewBlockchain("http://127.0.0.1:1", c.Interval)
+	require.NoError(t, err)
+	c.Nodes = append(c.Nodes, unreachable)
+
+	report := c.Check()
+	assert.Error(t, report.Nodes[2].Err)
+	assert.Len(t, report.Lagging(c.MaxLag), 1)
+	assert.Empty(t, report.Divergences)
+}
+
+func TestCompare(t *testing.T) {
+	var (
+		a = common.HexToHash("0x0a")
+		b = common.HexToHash("0x0b")
+	)
+	_, diverged := compare(1, []BlockView{{Hash: a, Txs: 2}, {Hash: a, Txs: 2}}, make([]error, 2))
+	assert.False(t, diverged)
+
+	d, diverged := compare(1, []BlockView{{Hash: a, Txs: 2}, {Hash: a, Txs: 3}}, make([]error, 2))
+	assert.True(t, diverged)
+	assert.False(t, d.Fork)
+
+	d, diverged = compare(1, []BlockView{{Hash: a, Txs: 2}, {Hash: b, Txs: 2}}, make([]error, 2))
+	assert.True(t, diverged)
+	assert.True(t, d.Fork)
+
+	// a node that failed is left out
+	_, diverged = compare(1, []BlockView{{Hash: a}, {Hash: b}, {Hash: a}}, []error{nil, assert.AnError, nil})
+	assert.False(t, diverged)
+}
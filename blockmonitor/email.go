@@ -0,0 +1,104 @@
+package blockmonitor
+
+import (
+	"crypto/tls"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Email sends alerts by email through an SMTP server
+type Email struct {
+	Addr string
+	// Auth authenticates to the server, nil when it does not need authentication
+	Auth smtp.Auth
+	From string
+	To   []string
+}
+
+// NewEmail returns an email notifier sending from from to every address of to through the server at addr,
+// authenticated as user when it is set
+func NewEmail(addr, user, password, from string, to []string) (*Email, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid SMTP address %s", addr)
+	}
+	if from == "" || len(to) == 0 {
+		return nil, errors.New("the sender and recipients of alert emails are required")
+	}
+	email := &Email{Addr: addr, From: from, To: to}
+	if user != "" {
+		email.Auth = smtp.PlainAuth("", user, password, host)
+	}
+	return email, nil
+}
+
+// Notify emails an alert, with caption as subject
+func (e *Email) Notify(content string, caption string) error {
+	msg := strings.Join([]string{
+		"From: " + e.From,
+		"To: " + strings.Join(e.To, ", "),
+		"Subject: [blockmonitor] " + caption,
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		content,
+	}, "\r\n")
+	if err := e.send([]byte(msg)); err != nil {
+		return errors.Wrapf(err, "can not email alert through %s", e.Addr)
+	}
+	return nil
+}
+
+// send delivers msg like smtp.SendMail, but gives up once notifyTimeout has passed
+func (e *Email) send(msg []byte) error {
+	host, _, err := net.SplitHostPort(e.Addr)
+	if err != nil {
+		return err
+	}
+	conn, err := net.DialTimeout("tcp", e.Addr, notifyTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(notifyTimeout)); err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if e.Auth != nil {
+		if err := c.Auth(e.Auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(e.From); err != nil {
+		return err
+	}
+	for _, to := range e.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
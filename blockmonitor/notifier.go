@@ -0,0 +1,160 @@
+package blockmonitor
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+)
+
+// notifyTimeout is the time a notifier has to deliver an alert
+const notifyTimeout = 10 * time.Second
+
+var (
+	notifyConfigFlag = cli.StringFlag{
+		Name:  "notify-config",
+		Usage: "YAML file of the notifiers alerts are sent to, the notifier flags are added to it",
+	}
+	slackWebhookFlag = cli.StringSliceFlag{
+		Name:  "slack-webhook",
+		Usage: "URL of a Slack incoming webhook alerts are sent to",
+	}
+	webhookFlag = cli.StringSliceFlag{
+		Name:  "webhook",
+		Usage: "URL alerts are posted to as JSON",
+	}
+	smtpAddrFlag = cli.StringFlag{
+		Name:  "smtp-addr",
+		Usage: "Address of the SMTP server alerts are emailed through (host:port)",
+	}
+	smtpUserFlag = cli.StringFlag{
+		Name:  "smtp-user",
+		Usage: "User of the SMTP server, no authentication when it is empty",
+	}
+	smtpPasswordFlag = cli.StringFlag{
+		Name:  "smtp-password",
+		Usage: "Password of the SMTP user",
+	}
+	emailFromFlag = cli.StringFlag{
+		Name:  "email-from",
+		Usage: "Sender of the alert emails",
+	}
+	emailToFlag = cli.StringSliceFlag{
+		Name:  "email-to",
+		Usage: "Recipient of the alert emails",
+	}
+)
+
+// Notifier sends alerts
+type Notifier interface {
+	// Notify sends an alert, caption is its kind (SOS, OK, ...)
+	Notify(content string, caption string) error
+}
+
+// Notifiers sends alerts to every notifier
+type Notifiers []Notifier
+
+// Notify sends an alert to every notifier, even when some of them fail
+func (n Notifiers) Notify(content string, caption string) error {
+	var failed []string
+	for _, notifier := range n {
+		if err := notifier.Notify(content, caption); err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	if len(failed) != 0 {
+		return errors.Errorf("%d of %d notifiers failed: %s", len(failed), len(n), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// NotifyConfig is the notifiers alerts are sent to, every notifier that is configured is active
+type NotifyConfig struct {
+	TelegramToken  string   `yaml:"telegram_token"`
+	TelegramChatID int64    `yaml:"telegram_chat_id"`
+	SlackWebhooks  []string `yaml:"slack_webhooks"`
+	Webhooks       []string `yaml:"webhooks"`
+	SMTPAddr       string   `yaml:"smtp_addr"`
+	SMTPUser       string   `yaml:"smtp_user"`
+	SMTPPassword   string   `yaml:"smtp_password"`
+	EmailFrom      string   `yaml:"email_from"`
+	EmailTo        []string `yaml:"email_to"`
+}
+
+// LoadNotifyConfig reads a notify config from a YAML file
+func LoadNotifyConfig(path string) (*NotifyConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &NotifyConfig{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, errors.Wrapf(err, "invalid notify config %s", path)
+	}
+	return cfg, nil
+}
+
+// NewNotifiers returns the notifiers of cfg
+func NewNotifiers(cfg *NotifyConfig) (Notifiers, error) {
+	var (
+		notifiers Notifiers
+		client    = &http.Client{Timeout: notifyTimeout}
+	)
+	if cfg.TelegramToken != "" {
+		telegram, err := NewTelegram(cfg.TelegramToken, cfg.TelegramChatID, client)
+		if err != nil {
+			return nil, errors.Wrap(err, "can not init telegram bot")
+		}
+		notifiers = append(notifiers, telegram)
+	}
+	for _, url := range cfg.SlackWebhooks {
+		notifiers = append(notifiers, &Slack{URL: url, Client: client})
+	}
+	for _, url := range cfg.Webhooks {
+		notifiers = append(notifiers, &Webhook{URL: url, Client: client})
+	}
+	if cfg.SMTPAddr != "" {
+		email, err := NewEmail(cfg.SMTPAddr, cfg.SMTPUser, cfg.SMTPPassword, cfg.EmailFrom, cfg.EmailTo)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, email)
+	}
+	return notifiers, nil
+}
+
+// NewNotifierFlags returns flags for the notifiers
+func NewNotifierFlags() []cli.Flag {
+	flags := []cli.Flag{notifyConfigFlag}
+	flags = append(flags, NewTeleClientFlag()...)
+	return append(flags, slackWebhookFlag, webhookFlag, smtpAddrFlag, smtpUserFlag, smtpPasswordFlag, emailFromFlag, emailToFlag)
+}
+
+// NewNotifiersFromFlags returns the notifiers of the notify config and flags
+func NewNotifiersFromFlags(ctx *cli.Context) (Notifiers, error) {
+	cfg := &NotifyConfig{}
+	if path := ctx.String(notifyConfigFlag.Name); path != "" {
+		var err error
+		if cfg, err = LoadNotifyConfig(path); err != nil {
+			return nil, err
+		}
+	}
+	if token := ctx.String(botAPITokenFlag.Name); token != "" {
+		cfg.TelegramToken = token
+		cfg.TelegramChatID = ctx.Int64(chatIDFlag.Name)
+	}
+	cfg.SlackWebhooks = append(cfg.SlackWebhooks, ctx.StringSlice(slackWebhookFlag.Name)...)
+	cfg.Webhooks = append(cfg.Webhooks, ctx.StringSlice(webhookFlag.Name)...)
+	if addr := ctx.String(smtpAddrFlag.Name); addr != "" {
+		cfg.SMTPAddr = addr
+		cfg.SMTPUser = ctx.String(smtpUserFlag.Name)
+		cfg.SMTPPassword = ctx.String(smtpPasswordFlag.Name)
+		cfg.EmailFrom = ctx.String(emailFromFlag.Name)
+		cfg.EmailTo = ctx.StringSlice(emailToFlag.Name)
+	}
+	return NewNotifiers(cfg)
+}
@@ -0,0 +1,236 @@
+package blockmonitor
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder is a notifier that keeps the alerts it is sent, or fails
+type recorder struct {
+	alerts []string
+	err    error
+}
+
+func (r *recorder) Notify(content string, caption string) error {
+	r.alerts = append(r.alerts, caption+": "+content)
+	return r.err
+}
+
+func TestNotifiers(t *testing.T) {
+	var (
+		ok     = &recorder{}
+		failed = &recorder{err: assert.AnError}
+	)
+	err := Notifiers{failed, ok}.Notify("block is stuck", "SOS")
+	assert.Error(t, err)
+	// a notifier that fails does not stop the next ones
+	assert.Equal(t, []string{"SOS: block is stuck"}, ok.alerts)
+	assert.NoError(t, Notifiers{ok}.Notify("node is ok", "OK"))
+}
+
+// newHTTPStandIn returns a server that keeps the bodies posted to it and answers with status
+func newHTTPStandIn(status int) (*httptest.Server, *[]string) {
+	var (
+		mu     sync.Mutex
+		bodies []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	return server, &bodies
+}
+
+func TestSlack(t *testing.T) {
+	server, bodies := newHTTPStandIn(http.StatusOK)
+	defer server.Close()
+
+	slack := &Slack{URL: server.URL, Client: server.Client()}
+	require.NoError(t, slack.Notify("block is stuck", "SOS"))
+	require.Len(t, *bodies, 1)
+	assert.JSONEq(t, `{"text": "*SOS*: block is stuck"}`, (*bodies)[0])
+}
+
+func TestWebhook(t *testing.T) {
+	server, bodies := newHTTPStandIn(http.StatusOK)
+	defer server.Close()
+
+	webhook := &Webhook{URL: server.URL, Client: server.Client()}
+	require.NoError(t, webhook.Notify("block is stuck", "SOS"))
+	require.Len(t, *bodies, 1)
+	var alert WebhookAlert
+	require.NoError(t, json.Unmarshal([]byte((*bodies)[0]), &alert))
+	assert.Equal(t, "SOS", alert.Caption)
+	assert.Equal(t, "block is stuck", alert.Content)
+	assert.False(t, alert.Time.IsZero())
+
+	failing, _ := newHTTPStandIn(http.StatusInternalServerError)
+	defer failing.Close()
+	webhook.URL = failing.URL
+	assert.Error(t, webhook.Notify("block is stuck", "SOS"))
+}
+
+// redirect sends every request to a stand-in server instead of its host
+type redirect struct {
+	to *url.URL
+}
+
+func (r redirect) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.URL.Scheme, req.URL.Host = r.to.Scheme, r.to.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestTelegram(t *testing.T) {
+	var sent url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bottoken/getMe":
+			_, _ = w.Write([]byte(`{"ok": true, "result": {"id": 1, "is_bot": true, "first_name": "monitor", "username": "monitor_bot"}}`))
+		case "/bottoken/sendMessage":
+			_ = r.ParseForm()
+			sent = r.PostForm
+			_, _ = w.Write([]byte(`{"ok": true, "result": {"message_id": 1, "date": 0, "chat": {"id": -42}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	to, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	telegram, err := NewTelegram("token", -42, &http.Client{Transport: redirect{to: to}})
+	require.NoError(t, err)
+	require.NoError(t, telegram.Notify("block is stuck", "SOS"))
+	assert.Equal(t, "-42", sent.Get("chat_id"))
+	assert.Equal(t, "<b>SOS</b>: block is stuck", sent.Get("text"))
+	assert.Equal(t, "html", sent.Get("parse_mode"))
+}
+
+// serveSMTP accepts a single SMTP session on l and sends the message it receives to messages
+func serveSMTP(l net.Listener, messages chan<- string) {
+	conn, err := l.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	var (
+		r      = bufio.NewReader(conn)
+		reply  = func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+		inData bool
+		data   []string
+	)
+	reply("220 localhost stand-in")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if inData {
+			if line == "." {
+				inData = false
+				messages <- strings.Join(data, "\n")
+				reply("250 OK")
+			} else {
+				data = append(data, line)
+			}
+			continue
+		}
+		switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); cmd {
+		case "EHLO", "HELO", "MAIL", "RCPT", "RSET", "NOOP":
+			reply("250 OK")
+		case "DATA":
+			inData = true
+			reply("354 go ahead")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func TestEmail(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	messages := make(chan string, 1)
+	go serveSMTP(l, messages)
+
+	email, err := NewEmail(l.Addr().String(), "", "", "monitor@evrynet.io", []string{"ops@evrynet.io", "dev@evrynet.io"})
+	require.NoError(t, err)
+	require.NoError(t, email.Notify("block is stuck", "SOS"))
+	msg := <-messages
+	assert.Contains(t, msg, "Subject: [blockmonitor] SOS")
+	assert.Contains(t, msg, "To: ops@evrynet.io, dev@evrynet.io")
+	assert.Contains(t, msg, "block is stuck")
+
+	_, err = NewEmail("localhost", "", "", "monitor@evrynet.io", []string{"ops@evrynet.io"})
+	assert.Error(t, err)
+	_, err = NewEmail("localhost:25", "", "", "", nil)
+	assert.Error(t, err)
+}
+
+func TestNewNotifiers(t *testing.T) {
+	file, err := ioutil.TempFile("", "notify*.yaml")
+	require.NoError(t, err)
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(`
+slack_webhooks: [http://127.0.0.1/slack]
+webhooks: [http://127.0.0.1/a, http://127.0.0.1/b]
+smtp_addr: 127.0.0.1:25
+smtp_user: monitor
+smtp_password: secret
+email_from: monitor@evrynet.io
+email_to: [ops@evrynet.io]
+`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	cfg, err := LoadNotifyConfig(file.Name())
+	require.NoError(t, err)
+	notifiers, err := NewNotifiers(cfg)
+	require.NoError(t, err)
+	require.Len(t, notifiers, 4)
+	assert.IsType(t, &Slack{}, notifiers[0])
+	assert.IsType(t, &Webhook{}, notifiers[1])
+	assert.IsType(t, &Webhook{}, notifiers[2])
+	assert.NotNil(t, notifiers[3].(*Email).Auth)
+
+	notifiers, err = NewNotifiers(&NotifyConfig{})
+	require.NoError(t, err)
+	assert.Empty(t, notifiers)
+}
+
+func TestConsistency_alert(t *testing.T) {
+	c, done := newTestConsistency(t, newTestChain(10, -1), newTestChain(10, 8))
+	defer done()
+	notifier := &recorder{}
+	c.Notifier = notifier
+
+	c.alert(c.Check())
+	c.alert(c.Check())
+	// a fork is alerted once
+	require.Len(t, notifier.alerts, 1)
+	assert.Contains(t, notifier.alerts[0], "FORK: ")
+
+	c.alert(&Report{Common: 10})
+	require.Len(t, notifier.alerts, 2)
+	assert.Contains(t, notifier.alerts[1], "OK: ")
+}
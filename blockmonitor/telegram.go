@@ -2,6 +2,7 @@ package blockmonitor
 
 import (
 	"fmt"
+	"net/http"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
 	"github.com/urfave/cli"
@@ -10,13 +11,11 @@ import (
 var (
 	botAPITokenFlag = cli.StringFlag{
 		Name:  "apiToken",
-		Usage: "The API token",
-		Value: "935265043:AAG02pbFdpOhT31ALs1tzlLTl_NVnxYuTF4",
+		Usage: "The API token of the telegram bot alerts are sent by",
 	}
 	chatIDFlag = cli.Int64Flag{
 		Name:  "chatId",
 		Usage: "The ID of group/chanel",
-		Value: -375817595,
 	}
 )
 
@@ -38,12 +37,16 @@ func NewTeleClientFromFlag(ctx *cli.Context) (*Telegram, error) {
 		botAPIToken = ctx.String(botAPITokenFlag.Name)
 		chatID      = ctx.Int64(chatIDFlag.Name)
 	)
+	return NewTelegram(botAPIToken, chatID, &http.Client{Timeout: notifyTimeout})
+}
 
+// NewTelegram returns a telegram client of the bot of token sending to the chat of chatID
+func NewTelegram(token string, chatID int64, client *http.Client) (*Telegram, error) {
 	telegram := &Telegram{
 		ChatId:  chatID,
 		IsDebug: false,
 	}
-	bot, err := tgbotapi.NewBotAPI(botAPIToken)
+	bot, err := tgbotapi.NewBotAPIWithClient(token, client)
 	if err != nil {
 		return nil, err
 	}
@@ -64,3 +67,8 @@ func (t *Telegram) SendMessage(content string, caption string) error {
 	}
 	return nil
 }
+
+// Notify sends an alert to the chat
+func (t *Telegram) Notify(content string, caption string) error {
+	return t.SendMessage(content, caption)
+}
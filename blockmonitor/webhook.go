@@ -0,0 +1,60 @@
+package blockmonitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Slack sends alerts to a Slack incoming webhook
+type Slack struct {
+	URL    string
+	Client *http.Client
+}
+
+// Notify posts an alert to the webhook
+func (s *Slack) Notify(content string, caption string) error {
+	return postJSON(s.Client, s.URL, map[string]string{"text": fmt.Sprintf("*%s*: %s", caption, content)})
+}
+
+// WebhookAlert is the JSON document a Webhook posts
+type WebhookAlert struct {
+	Caption string    `json:"caption"`
+	Content string    `json:"content"`
+	Time    time.Time `json:"time"`
+}
+
+// Webhook posts alerts as JSON to a URL
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+// Notify posts an alert to the URL
+func (w *Webhook) Notify(content string, caption string) error {
+	return postJSON(w.Client, w.URL, WebhookAlert{Caption: caption, Content: content, Time: time.Now().UTC()})
+}
+
+// postJSON posts v as JSON to url and returns an error when the response is not a success
+func postJSON(client *http.Client, url string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return errors.Errorf("%s returned %s: %s", url, resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
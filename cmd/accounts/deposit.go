@@ -5,6 +5,7 @@ import (
 
 	"github.com/evrynet-official/evrynet-tools/accounts/depositor"
 	"github.com/evrynet-official/evrynet-tools/lib/log"
+	"github.com/evrynet-official/evrynet-tools/lib/metrics"
 )
 
 func deposit(ctx *cli.Context) error {
@@ -13,9 +14,15 @@ func deposit(ctx *cli.Context) error {
 		return err
 	}
 	defer flush()
+	if err := metrics.ServeFromFlags(ctx); err != nil {
+		return err
+	}
 	dp, err := depositor.NewDepositorFromFlag(ctx, zap)
 	if err != nil {
-		zap.Error("cannot create depositor", "error", err)
+		zap.Errorw("cannot create depositor", "error", err)
+		return err
 	}
-	return dp.CheckAndDeposit()
+	err = dp.CheckAndDeposit()
+	dp.PrintClientStats()
+	return err
 }
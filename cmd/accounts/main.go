@@ -9,6 +9,7 @@ import (
 	"github.com/evrynet-official/evrynet-tools/accounts"
 	"github.com/evrynet-official/evrynet-tools/accounts/depositor"
 
+	"github.com/evrynet-official/evrynet-tools/lib/metrics"
 	"github.com/evrynet-official/evrynet-tools/lib/node"
 )
 
@@ -42,6 +43,7 @@ func accountsCommand() []cli.Command {
 	}
 	depositCmd.Flags = depositor.NewDepositFlags()
 	depositCmd.Flags = append(depositCmd.Flags, node.NewEvrynetNodeFlags()...)
+	depositCmd.Flags = append(depositCmd.Flags, metrics.NewMetricsFlags()...)
 
 	return []cli.Command{createAccountsCmd, depositCmd}
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/urfave/cli"
 
 	"github.com/evrynet-official/evrynet-tools/blockmonitor"
+	"github.com/evrynet-official/evrynet-tools/lib/metrics"
 )
 
 const (
@@ -20,14 +21,22 @@ func blcMonitor(ctx *cli.Context) {
 	client := &Client{
 		SendCount: 0,
 	}
+	if err := metrics.ServeFromFlags(ctx); err != nil {
+		log.Printf("can not expose metrics %s", err.Error())
+		return
+	}
 
-	teleClient, err := blockmonitor.NewTeleClientFromFlag(ctx)
+	notifiers, err := blockmonitor.NewNotifiersFromFlags(ctx)
 	if err != nil {
-		log.Printf("can not init telegram bot %s", err.Error())
+		log.Printf("can not init notifiers %s", err.Error())
+		return
+	}
+	if len(notifiers) == 0 {
+		log.Print("no notifier, set --apiToken, --slack-webhook, --webhook, --smtp-addr or --notify-config")
 		return
 	}
-	log.Print("Connected to telegram")
-	client.TeleClient = teleClient
+	log.Printf("Sending alerts to %d notifiers", len(notifiers))
+	client.Notifier = notifiers
 
 	blcClient, err := blockmonitor.NewBlcClientFromFlags(ctx)
 	if err != nil {
@@ -38,6 +47,8 @@ func blcMonitor(ctx *cli.Context) {
 	}
 	client.BlcClient = blcClient
 	log.Print("evrynet client is created")
+	sub := blcClient.WatchHeads()
+	defer sub.Unsubscribe()
 
 	ticker := time.NewTicker(client.BlcClient.Duration * time.Second)
 	for range ticker.C {
@@ -93,13 +104,19 @@ func sendAlert(client *Client, msg string, caption string, forceSend bool) {
 	if forceSend {
 		// send message not increase counter
 		log.Printf("================send msg: %s", msg)
-		client.TeleClient.SendMessage(msg, caption)
+		if err := client.Notifier.Notify(msg, caption); err != nil {
+			log.Printf("can not send alert %s", err.Error())
+		}
+		metrics.Alerts.WithLabelValues(caption).Inc()
 		return
 	}
 	if client.SendCount >= MaxTimes {
 		return
 	}
 	log.Printf("================send msg: %s", msg)
-	client.TeleClient.SendMessage(msg, caption)
+	if err := client.Notifier.Notify(msg, caption); err != nil {
+		log.Printf("can not send alert %s", err.Error())
+	}
+	metrics.Alerts.WithLabelValues(caption).Inc()
 	client.SendCount++
 }
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/urfave/cli"
+
+	"github.com/evrynet-official/evrynet-tools/blockmonitor"
+	"github.com/evrynet-official/evrynet-tools/lib/metrics"
+)
+
+func consistency(ctx *cli.Context) error {
+	if err := metrics.ServeFromFlags(ctx); err != nil {
+		return err
+	}
+	check, err := blockmonitor.NewConsistencyFromFlags(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		stop    = make(chan struct{})
+		signals = make(chan os.Signal, 1)
+	)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+	return check.Run(stop)
+}
@@ -7,19 +7,20 @@ import (
 	"github.com/urfave/cli"
 
 	"github.com/evrynet-official/evrynet-tools/blockmonitor"
+	"github.com/evrynet-official/evrynet-tools/lib/metrics"
 	"github.com/evrynet-official/evrynet-tools/lib/node"
 )
 
 type Client struct {
-	TeleClient *blockmonitor.Telegram
-	BlcClient  *blockmonitor.Blockchain
-	SendCount  int
+	Notifier  blockmonitor.Notifier
+	BlcClient *blockmonitor.Blockchain
+	SendCount int
 }
 
 func main() {
 	app := cli.NewApp()
 	app.Name = "blcMonitor"
-	app.Usage = "sends messages to telegram when node dont increase blocks"
+	app.Usage = "sends alerts when node dont increase blocks"
 	app.Version = "0.0.1"
 	app.Commands = healthCheckCommand()
 
@@ -33,12 +34,24 @@ func healthCheckCommand() []cli.Command {
 	healthCheckCmd := cli.Command{
 		Action:      blcMonitor,
 		Name:        "start",
-		Usage:       "Alert to telegram when block is stuck",
-		Description: `Alert to telegram when block is stuck`,
+		Usage:       "Alert when block is stuck",
+		Description: `Alert to telegram, slack, webhooks or email when block is stuck`,
 	}
-	healthCheckCmd.Flags = blockmonitor.NewTeleClientFlag()
+	healthCheckCmd.Flags = blockmonitor.NewNotifierFlags()
 	healthCheckCmd.Flags = append(healthCheckCmd.Flags, blockmonitor.NewBlcClientFlag()...)
 	healthCheckCmd.Flags = append(healthCheckCmd.Flags, node.NewEvrynetNodeFlags()...)
+	healthCheckCmd.Flags = append(healthCheckCmd.Flags, metrics.NewMetricsFlags()...)
 
-	return []cli.Command{healthCheckCmd}
+	consistencyCmd := cli.Command{
+		Action:      consistency,
+		Name:        "consistency",
+		Usage:       "Compare the heads and blocks of several nodes",
+		Description: `Reports the lag of every --rpcendpoint behind the highest one, and the heights at which their blocks or tx counts differ. Fails when nodes are forked, and alerts when they fork or recover with --continuous`,
+	}
+	consistencyCmd.Flags = blockmonitor.NewConsistencyFlags()
+	consistencyCmd.Flags = append(consistencyCmd.Flags, node.NewEvrynetNodeFlags()...)
+	consistencyCmd.Flags = append(consistencyCmd.Flags, metrics.NewMetricsFlags()...)
+	consistencyCmd.Flags = append(consistencyCmd.Flags, blockmonitor.NewNotifierFlags()...)
+
+	return []cli.Command{healthCheckCmd, consistencyCmd}
 }
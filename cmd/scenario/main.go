@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli"
+
+	"github.com/evrynet-official/evrynet-tools/lib/log"
+	"github.com/evrynet-official/evrynet-tools/scenario"
+)
+
+func main() {
+	app := cli.NewApp()
+	app.Name = "scenario"
+	app.Usage = "The scenario command line interface"
+	app.Version = "0.0.1"
+	app.Commands = []cli.Command{
+		{
+			Action:      run,
+			Name:        "run",
+			Usage:       "runs the steps of a scenario file in order",
+			Description: "Runs generate, deposit, deploy, flood, vote, metrics, assert and sweep steps in order, passing the outputs of a step to the params of the next ones as ${step.output}",
+			Flags:       scenario.NewScenarioFlags(),
+		},
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(c *cli.Context) error {
+	zap, flush, err := log.NewSugaredLogger(c)
+	if err != nil {
+		return err
+	}
+	defer flush()
+
+	runner, err := scenario.NewRunnerFromFlags(c, zap)
+	if err != nil {
+		return err
+	}
+	err = runner.Run()
+	runner.Print()
+	return err
+}
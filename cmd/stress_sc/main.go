@@ -7,6 +7,7 @@ import (
 	"github.com/urfave/cli"
 
 	"github.com/evrynet-official/evrynet-tools/lib/node"
+	"github.com/evrynet-official/evrynet-tools/lib/results"
 	sc "github.com/evrynet-official/evrynet-tools/stakingcontract"
 )
 
@@ -26,6 +27,7 @@ func main() {
 func stakingCommands() []cli.Command {
 	stressFlags := sc.NewStressTestFlag()
 	stressFlags = append(stressFlags, node.NewEvrynetNodeFlags()...)
+	stressFlags = append(stressFlags, results.NewResultsFlags()...)
 
 	stressVotesCmd := cli.Command{
 		Action:      stressVoters,
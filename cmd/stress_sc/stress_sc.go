@@ -4,6 +4,7 @@ import (
 	"context"
 	"math"
 	"math/big"
+	"sync/atomic"
 	"time"
 
 	"github.com/urfave/cli"
@@ -11,13 +12,13 @@ import (
 
 	"golang.org/x/sync/errgroup"
 
-	"github.com/Evrynetlabs/evrynet-node/accounts/abi/bind"
 	"github.com/Evrynetlabs/evrynet-node/common"
-	"github.com/Evrynetlabs/evrynet-node/core/types"
 
 	"github.com/evrynet-official/evrynet-tools/accounts"
 	"github.com/evrynet-official/evrynet-tools/accounts/depositor"
 	"github.com/evrynet-official/evrynet-tools/lib/log"
+	"github.com/evrynet-official/evrynet-tools/lib/node"
+	"github.com/evrynet-official/evrynet-tools/lib/results"
 	"github.com/evrynet-official/evrynet-tools/lib/txutil"
 	sc "github.com/evrynet-official/evrynet-tools/stakingcontract"
 )
@@ -47,13 +48,34 @@ func stressVoters(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	err = voteForCandidate(stakingClient, accounts, stakingClient.Candidate)
+	start := time.Now()
+	votes, err := voteForCandidate(stakingClient, accounts, stakingClient.Candidate)
 	if err != nil {
 		return err
 	}
+	elapsed := time.Since(start)
 
 	timeStats(stakingClient)
-	return nil
+	return results.SaveFromFlags(ctx, votes.run(elapsed))
+}
+
+// voteStats counts the votes mined successfully and the time they took from sending
+type voteStats struct {
+	success int64
+	totalMs int64
+}
+
+func (v *voteStats) run(elapsed time.Duration) *results.Run {
+	run := results.NewRun("stress_sc stressvotes")
+	run.Metrics["votes"] = float64(v.success)
+	run.Metrics["elapsed_s"] = elapsed.Seconds()
+	if elapsed > 0 {
+		run.Metrics[results.TPS] = float64(v.success) / elapsed.Seconds()
+	}
+	if v.success > 0 {
+		run.Metrics[results.ConfirmationTime] = float64(v.totalMs) / float64(v.success)
+	}
+	return run
 }
 
 func timeStats(stakingClient *sc.ContractClient) {
@@ -95,8 +117,9 @@ func timeStats(stakingClient *sc.ContractClient) {
 
 }
 
-func voteForCandidate(contractClient *sc.ContractClient, voters []*accounts.Account, candidate common.Address) error {
+func voteForCandidate(contractClient *sc.ContractClient, voters []*accounts.Account, candidate common.Address) (*voteStats, error) {
 	var (
+		stats  = &voteStats{}
 		gr     = errgroup.Group{}
 		logger = contractClient.Logger.With("func", "voteForCandidate", "candidate", candidate.Hex())
 	)
@@ -123,7 +146,7 @@ func voteForCandidate(contractClient *sc.ContractClient, voters []*accounts.Acco
 					return err
 				}
 
-				optTrans := bind.NewKeyedTransactor(voterPk)
+				optTrans := node.NewKeyedTransactor(voterPk, contractClient.Signer)
 				optTrans.Nonce = new(big.Int).SetUint64(nonce)
 				contractClient.TranOps = optTrans
 
@@ -146,6 +169,8 @@ func voteForCandidate(contractClient *sc.ContractClient, voters []*accounts.Acco
 
 			}
 
+			atomic.AddInt64(&stats.success, numberSuccess)
+			atomic.AddInt64(&stats.totalMs, totalTime)
 			if numberSuccess > 0 {
 				avgTime := totalTime / numberSuccess
 				logger.Infow("************************** summary", "voters", numberSuccess, "total time (ms)", totalTime, "avg (ms)", avgTime)
@@ -156,10 +181,10 @@ func voteForCandidate(contractClient *sc.ContractClient, voters []*accounts.Acco
 	}
 
 	if err := gr.Wait(); err != nil {
-		return err
+		return nil, err
 	}
 	logger.Infow("all voters have sent votes for candidate", "total_account", len(voters))
-	return nil
+	return stats, nil
 }
 
 func sendEvrToken(stakingClient *sc.ContractClient, voters []*accounts.Account) error {
@@ -168,12 +193,9 @@ func sendEvrToken(stakingClient *sc.ContractClient, voters []*accounts.Account)
 		expectedAmount = new(big.Int).Exp(new(big.Int).SetUint64(10), new(big.Int).SetUint64(18), nil)
 	)
 
-	optTrans := bind.NewKeyedTransactor(stakingClient.SenderPk)
-	optTrans.Signer = func(signer types.Signer, from common.Address, tx *types.Transaction) (*types.Transaction, error) {
-		return types.SignTx(tx, signer, stakingClient.SenderPk)
-	}
+	optTrans := node.NewKeyedTransactor(stakingClient.SenderPk, stakingClient.Signer)
 	dep := depositor.NewDepositor(stakingClient.Logger, optTrans, optTrans.From, voters, stakingClient.Client, expectedAmount, len(voters),
-		depositor.WithGasLimit(gasLimit))
+		depositor.WithGasLimit(gasLimit), depositor.WithSigner(stakingClient.Signer))
 
 	return dep.DepositCoreAccounts()
 }
@@ -2,9 +2,12 @@ package main
 
 import (
 	"fmt"
+	"log"
 	"os"
 
 	"github.com/evrynet-official/evrynet-tools/accounts"
+	"github.com/evrynet-official/evrynet-tools/lib/metrics"
+	"github.com/evrynet-official/evrynet-tools/lib/results"
 	"github.com/evrynet-official/evrynet-tools/tx_flood"
 	"github.com/urfave/cli"
 )
@@ -16,7 +19,22 @@ func main() {
 	app.Version = "0.0.1"
 	app.Flags = append(app.Flags, accounts.NewAccountsFlags()...)
 	app.Flags = append(app.Flags, tx_flood.NewTxFloodFlags()...)
+	app.Flags = append(app.Flags, results.NewResultsFlags()...)
 	app.Action = run
+	app.Commands = append(distributedCommands(), presignCommands()...)
+	app.Commands = append(app.Commands, cli.Command{
+		Action:      runAdversarial,
+		Name:        "adversarial",
+		Usage:       "sends malformed txs and records how the node rejects them",
+		Description: "Sends txs with bad signatures, nonce gaps, underpriced gas, gas above the block limit, oversized data, a wrong chain id and duplicates while the honest flood of the other flags runs, and fails if the node accepts a malformed tx or the honest flood fails",
+		Flags:       tx_flood.NewAdversaryFlags(),
+	}, cli.Command{
+		Action:      runProbe,
+		Name:        "probe",
+		Usage:       "finds the txpool limits of a node",
+		Description: "Sends a growing queue of txs in nonce order, txs after a nonce gap and replacements at increasing gas prices, watches txpool_status and txpool_content and reports the limits found",
+		Flags:       tx_flood.NewProbeFlags(),
+	})
 
 	if err := app.Run(os.Args); err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -25,13 +43,181 @@ func main() {
 }
 
 func run(c *cli.Context) error {
+	if err := metrics.ServeFromFlags(c); err != nil {
+		return err
+	}
 	tf, err := tx_flood.NewTxFloodFromFlags(c)
 	if err != nil {
 		return err
 	}
 
-	if err := tf.Start(); err != nil {
+	err = tf.Start()
+	tf.EvrClient.PrintStats()
+	if tf.Backpressure != nil {
+		tf.Backpressure.Print()
+	}
+	return saveRun(c, tf.Result().Run("tx_flood"), err)
+}
+
+func runAgent(c *cli.Context) error {
+	server, err := tx_flood.NewAgentServerFromFlags(c)
+	if err != nil {
+		return err
+	}
+	log.Printf("agent is listening on %s", server.Addr)
+	return server.ListenAndServe()
+}
+
+func runCoordinator(c *cli.Context) error {
+	coordinator, err := tx_flood.NewCoordinatorFromFlags(c)
+	if err != nil {
+		return err
+	}
+
+	report, err := coordinator.Run()
+	if report != nil {
+		report.Print()
+	}
+	if report == nil {
 		return err
 	}
+	return saveRun(c, report.Total.Run("tx_flood coordinator"), err)
+}
+
+func runPresign(c *cli.Context) error {
+	tf, err := tx_flood.NewTxFloodFromFlags(c)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(tx_flood.OutFileFromFlags(c))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	n, err := tf.Presign(file)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d signed txs written to %s\n", n, file.Name())
 	return nil
 }
+
+func runReplay(c *cli.Context) error {
+	replayer, err := tx_flood.NewReplayerFromFlags(c)
+	if err != nil {
+		return err
+	}
+
+	result, err := replayer.Start()
+	fmt.Println("-----------Replay Stats----------------")
+	fmt.Printf("Sent: %d, failed: %d, elapsed: %s, TPS: %.2f\n", result.Sent, result.Failed, result.Elapsed, result.TPS())
+	replayer.EvrClient.PrintStats()
+	return saveRun(c, result.Run("tx_flood replay"), err)
+}
+
+// saveRun saves run to the results file of flags, recording the error of the run if any, and returns that error
+func saveRun(c *cli.Context, run *results.Run, err error) error {
+	if err != nil {
+		run.Error = err.Error()
+	}
+	if sErr := results.SaveFromFlags(c, run); sErr != nil {
+		fmt.Fprintln(os.Stderr, "failed to save run:", sErr)
+	}
+	return err
+}
+
+func runMirror(c *cli.Context) error {
+	mirror, err := tx_flood.NewMirrorFromFlags(c)
+	if err != nil {
+		return err
+	}
+
+	accs, err := mirror.MapSenders()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d captured txs from %d senders mapped to accounts of seed %s\n", len(mirror.Captured), len(accs), mirror.Seed)
+
+	result, err := mirror.Start()
+	fmt.Println("-----------Mirror Stats----------------")
+	fmt.Printf("Sent: %d, failed: %d, elapsed: %s, TPS: %.2f\n", result.Sent, result.Failed, result.Elapsed, result.TPS())
+	mirror.EvrClient.PrintStats()
+	return err
+}
+
+func runAdversarial(c *cli.Context) error {
+	adversary, err := tx_flood.NewAdversaryFromFlags(c)
+	if err != nil {
+		return err
+	}
+
+	report, err := adversary.Start()
+	if report != nil {
+		report.Print()
+	}
+	adversary.EvrClient.PrintStats()
+	return err
+}
+
+func runProbe(c *cli.Context) error {
+	probe, err := tx_flood.NewProbeFromFlags(c)
+	if err != nil {
+		return err
+	}
+
+	report, err := probe.Start()
+	if report != nil {
+		report.Print()
+	}
+	return err
+}
+
+func presignCommands() []cli.Command {
+	presignCmd := cli.Command{
+		Action:      runPresign,
+		Name:        "presign",
+		Usage:       "writes a file of signed txs with planned nonces",
+		Description: "Signs num-tx-per-acc txs for every account ahead of time so that signing does not limit the send rate",
+		Flags:       tx_flood.NewPresignFlags(),
+	}
+
+	replayCmd := cli.Command{
+		Action:      runReplay,
+		Name:        "replay",
+		Usage:       "pushes a file of presigned txs at a target rate",
+		Description: "Sends the txs written by presign in order at the given rate, the txs of an account are sent in nonce order",
+		Flags:       tx_flood.NewReplayFlags(),
+	}
+
+	mirrorCmd := cli.Command{
+		Action:      runMirror,
+		Name:        "mirror",
+		Usage:       "replays txs captured by tx_metric capture with generated accounts",
+		Description: "Maps the original senders to accounts generated from seed, re-signs the txs and sends them keeping the time between them, divided by speedup",
+		Flags:       tx_flood.NewMirrorFlags(),
+	}
+
+	return []cli.Command{presignCmd, replayCmd, mirrorCmd}
+}
+
+func distributedCommands() []cli.Command {
+	agentCmd := cli.Command{
+		Action:      runAgent,
+		Name:        "agent",
+		Usage:       "waits for a coordinator to assign a share of the flood",
+		Description: "Listens for jobs from a coordinator and floods the network from the assigned account range",
+		Flags:       tx_flood.NewAgentFlags(),
+	}
+
+	coordinatorCmd := cli.Command{
+		Action:      runCoordinator,
+		Name:        "coordinator",
+		Usage:       "splits the flood between agents and merges their metrics",
+		Description: "Gives each agent a disjoint account range and rate share, starts them at the same time and merges their metrics into one report",
+		Flags:       tx_flood.NewCoordinatorFlags(),
+	}
+
+	return []cli.Command{agentCmd, coordinatorCmd}
+}
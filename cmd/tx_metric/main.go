@@ -3,10 +3,14 @@ package main
 import (
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/urfave/cli"
 
 	"github.com/evrynet-official/evrynet-tools/lib/node"
+	"github.com/evrynet-official/evrynet-tools/lib/results"
 	"github.com/evrynet-official/evrynet-tools/tx_metric"
 )
 
@@ -28,11 +32,15 @@ func byTime(c *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	defer tm.Close()
 
 	if err := tm.MetricByTime(); err != nil {
 		return err
 	}
-	return nil
+	if err := tx_metric.ExportFromFlags(c, tm, "tx_metric bytime"); err != nil {
+		return err
+	}
+	return results.SaveFromFlags(c, tm.Summary.Run("tx_metric bytime"))
 }
 
 func byBlock(c *cli.Context) error {
@@ -40,13 +48,73 @@ func byBlock(c *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	defer tm.Close()
 
 	if err := tm.MetricByBlock(); err != nil {
 		return err
 	}
+	if err := tx_metric.ExportFromFlags(c, tm, "tx_metric byblock"); err != nil {
+		return err
+	}
+	return results.SaveFromFlags(c, tm.Summary.Run("tx_metric byblock"))
+}
+
+func live(c *cli.Context) error {
+	lv, err := tx_metric.NewLiveFromFlags(c)
+	if err != nil {
+		return err
+	}
+
+	var (
+		stop    = make(chan struct{})
+		signals = make(chan os.Signal, 1)
+		timeout <-chan time.Time
+	)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	if d := c.Duration("duration"); d > 0 {
+		timeout = time.After(d)
+	}
+	go func() {
+		select {
+		case <-signals:
+		case <-timeout:
+		}
+		close(stop)
+	}()
+
+	if err := lv.Run(stop); err != nil {
+		return err
+	}
+	return results.SaveFromFlags(c, lv.Summary().Run("tx_metric live"))
+}
+
+func cache(c *cli.Context) error {
+	return tx_metric.FillFromFlags(c)
+}
+
+func capture(c *cli.Context) error {
+	n, err := tx_metric.CaptureFromFlags(c)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d txs captured\n", n)
+	return nil
+}
+
+func validators(c *cli.Context) error {
+	v, err := tx_metric.ValidatorsFromFlags(c)
+	if err != nil {
+		return err
+	}
+	v.Print()
 	return nil
 }
 
+func compare(c *cli.Context) error {
+	return results.CompareFromFlags(c)
+}
+
 func metricsCommand() []cli.Command {
 	byBlockCommand := cli.Command{
 		Action:      byBlock,
@@ -62,8 +130,51 @@ func metricsCommand() []cli.Command {
 		Description: "The total metrics of the network will be aggregated by the first block that is higher than input block and has more than 1 transaction",
 	}
 	flags := append(tx_metric.NewTxMetricFlags(), node.NewEvrynetNodeFlags()...)
+	flags = append(flags, results.NewResultsFlags()...)
+	flags = append(flags, tx_metric.NewExportFlags()...)
 	byBlockCommand.Flags = flags
 	bytimeCommand.Flags = flags
 
-	return []cli.Command{byBlockCommand, bytimeCommand}
+	liveFlags := append(tx_metric.NewLiveFlags(), node.NewEvrynetNodeFlags()...)
+	liveCommand := cli.Command{
+		Action:      live,
+		Name:        "live",
+		Usage:       "follow new blocks and print rolling metrics",
+		Description: "Prints TPS, txs per block, gas usage and block time over rolling windows of the latest blocks, and the metrics of every block seen when interrupted",
+		Flags:       append(liveFlags, results.NewResultsFlags()...),
+	}
+
+	cacheCommand := cli.Command{
+		Action:      cache,
+		Name:        "cache",
+		Usage:       "fetch a block range into the block cache",
+		Description: "Fills the --cache directory with the blocks from start-block to end-block, and their receipts with --receipts, so that byblock and bytime read them with --cache --offline without the node",
+		Flags:       append(tx_metric.NewFillFlags(), node.NewEvrynetNodeFlags()...),
+	}
+
+	captureCommand := cli.Command{
+		Action:      capture,
+		Name:        "capture",
+		Usage:       "record the txs of a block range to replay them on another network",
+		Description: "Writes the txs from start-block to end-block with their senders and time since start-block, to be replayed by tx_flood mirror",
+		Flags:       append(tx_metric.NewCaptureFlags(), node.NewEvrynetNodeFlags()...),
+	}
+
+	validatorsCommand := cli.Command{
+		Action:      validators,
+		Name:        "validators",
+		Usage:       "report the blocks proposed by every validator of a block range",
+		Description: "Attributes every block from start-block to end-block to its proposer and prints the blocks, average txs and block time after the blocks of every proposer, and the candidates of the staking contract that proposed no block",
+		Flags:       append(tx_metric.NewValidatorsFlags(), node.NewEvrynetNodeFlags()...),
+	}
+
+	compareCommand := cli.Command{
+		Action:      compare,
+		Name:        "compare",
+		Usage:       "compare a saved run against a baseline",
+		Description: "Prints the change of every metric of a run saved with --results from a baseline run and fails when TPS drops, or latency or block time rises, beyond the thresholds",
+		Flags:       results.NewCompareFlags(),
+	}
+
+	return []cli.Command{byBlockCommand, bytimeCommand, liveCommand, cacheCommand, captureCommand, validatorsCommand, compareCommand}
 }
@@ -0,0 +1,30 @@
+package deployment
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// testnetChainID is the chain id of the Evrynet testnet, set in the genesis block of
+// deploy/testnet/nodes/bin/genesis.json of evrynet-node
+const testnetChainID = 15
+
+// Parse returns the deployment named s
+func Parse(s string) (Deployment, error) {
+	for d := Mainnet; d <= TestNet; d++ {
+		if d.String() == s {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown deployment %s", s)
+}
+
+// ChainID returns the chain id of the network of the deployment, or an error when it is not known
+func (d Deployment) ChainID() (*big.Int, error) {
+	switch d {
+	case TestNet:
+		return big.NewInt(testnetChainID), nil
+	default:
+		return nil, fmt.Errorf("the chain id of the %s deployment is not known", d)
+	}
+}
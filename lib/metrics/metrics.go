@@ -0,0 +1,161 @@
+package metrics
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/urfave/cli"
+
+	"github.com/Evrynetlabs/evrynet-node/rpc"
+)
+
+const namespace = "evrynet_tools"
+
+var (
+	// TxsSent counts the txs accepted by the node
+	TxsSent = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "txs_sent_total",
+		Help:      "Number of txs accepted by the node.",
+	})
+	// TxsFailed counts the txs the node did not accept, by error class
+	TxsFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "txs_failed_total",
+		Help:      "Number of txs the node did not accept, by error class.",
+	}, []string{"class"})
+	// TxsInFlight is the number of txs sent but not mined yet
+	TxsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "txs_in_flight",
+		Help:      "Number of txs sent but not mined yet.",
+	})
+	// NonceResyncs counts the times the nonce of an account was taken again from the node
+	NonceResyncs = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "nonce_resyncs_total",
+		Help:      "Number of times the nonce of an account differed from the pending nonce of the node.",
+	})
+	// LatestBlock is the number of the latest block observed
+	LatestBlock = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "latest_block",
+		Help:      "Number of the latest block observed.",
+	})
+	// Alerts counts the alerts sent, by caption
+	Alerts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "alerts_total",
+		Help:      "Number of alerts sent, by caption.",
+	}, []string{"caption"})
+	// RPCDuration is the latency of RPC requests, by endpoint and method
+	RPCDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rpc_duration_seconds",
+		Help:      "Latency of RPC requests, by endpoint and method.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 14),
+	}, []string{"endpoint", "method"})
+	// NodeLag is the number of blocks a node is behind the highest node checked, by endpoint
+	NodeLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "node_lag_blocks",
+		Help:      "Number of blocks a node is behind the highest node checked, by endpoint.",
+	}, []string{"endpoint"})
+	// Forks counts the heights found at which nodes have different blocks
+	Forks = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "forks_total",
+		Help:      "Number of heights found at which nodes have different blocks.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(TxsSent, TxsFailed, TxsInFlight, NonceResyncs, LatestBlock, Alerts, RPCDuration, NodeLag, Forks)
+}
+
+// errorClasses maps the errors returned by the txpool of the node to a class, the first match wins
+var errorClasses = []struct {
+	match string
+	class string
+}{
+	{"nonce too low", "nonce_too_low"},
+	{"replacement transaction underpriced", "replacement_underpriced"},
+	{"underpriced", "underpriced"},
+	{"gasPrice is different", "invalid_gas_price"},
+	{"insufficient funds", "insufficient_funds"},
+	{"known transaction", "known"},
+	{"txpool is full", "txpool_full"},
+	{"exceeds block gas limit", "gas_limit"},
+	{"intrinsic gas too low", "intrinsic_gas"},
+	{"invalid sender", "invalid_sender"},
+	{"oversized data", "oversized_data"},
+}
+
+// ErrorClass returns the class of an error returned when sending a tx:
+// a txpool error, other for another error of the node, or rpc when the node could not be reached
+func ErrorClass(err error) string {
+	err = errors.Cause(err)
+	msg := err.Error()
+	for _, c := range errorClasses {
+		if strings.Contains(msg, c.match) {
+			return c.class
+		}
+	}
+	if _, ok := err.(rpc.Error); ok {
+		return "other"
+	}
+	return "rpc"
+}
+
+// TxFailed counts a tx the node did not accept
+func TxFailed(err error) {
+	TxsFailed.WithLabelValues(ErrorClass(err)).Inc()
+}
+
+// ObserveRPC records the latency of a request to endpoint
+func ObserveRPC(endpoint, method string, elapsed time.Duration) {
+	RPCDuration.WithLabelValues(endpoint, method).Observe(elapsed.Seconds())
+}
+
+// MetricsAddrFlag is the address the metrics are exposed on
+var MetricsAddrFlag = cli.StringFlag{
+	Name:  "metrics-addr",
+	Usage: "Address to expose Prometheus metrics on at /metrics (e.g. :9100), disabled when empty",
+}
+
+// NewMetricsFlags return flags to expose metrics
+func NewMetricsFlags() []cli.Flag {
+	return []cli.Flag{MetricsAddrFlag}
+}
+
+// ServeFromFlags exposes the metrics in the background when the metrics address is set
+func ServeFromFlags(ctx *cli.Context) error {
+	addr := ctx.String(MetricsAddrFlag.Name)
+	if addr == "" {
+		return nil
+	}
+	return Serve(addr)
+}
+
+// Serve exposes the metrics on addr at /metrics in the background
+func Serve(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	log.Printf("metrics are exposed on %s/metrics", listener.Addr())
+	go func() {
+		if err := http.Serve(listener, mux); err != nil {
+			log.Printf("metrics server stopped: %s", err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type rpcError struct{ msg string }
+
+func (e rpcError) Error() string  { return e.msg }
+func (e rpcError) ErrorCode() int { return -32000 }
+
+func TestErrorClass(t *testing.T) {
+	assert.Equal(t, "nonce_too_low", ErrorClass(rpcError{"nonce too low"}))
+	assert.Equal(t, "replacement_underpriced", ErrorClass(rpcError{"replacement transaction underpriced"}))
+	assert.Equal(t, "underpriced", ErrorClass(pkgerrors.Wrap(rpcError{"transaction underpriced"}, "failed to send")))
+	assert.Equal(t, "other", ErrorClass(pkgerrors.Wrap(rpcError{"execution reverted"}, "failed to send")))
+	assert.Equal(t, "rpc", ErrorClass(errors.New("connection refused")))
+}
+
+func TestServe(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	addr := listener.Addr().String()
+	assert.NoError(t, listener.Close())
+
+	assert.NoError(t, Serve(addr))
+	TxsSent.Inc()
+	TxFailed(rpcError{"known transaction"})
+
+	resp, err := http.Get("http://" + addr + "/metrics")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), "evrynet_tools_txs_sent_total 1")
+	assert.Contains(t, string(body), `evrynet_tools_txs_failed_total{class="known"} 1`)
+}
@@ -8,6 +8,7 @@ import (
 
 const (
 	rpcEndpointFlag = "rpcendpoint"
+	rpcPolicyFlag   = "rpc-policy"
 	evrynetEndpoint = "http://52.220.52.16:22001"
 )
 
@@ -19,15 +20,46 @@ func EvrynetEndpoint() string {
 // NewEvrynetNodeFlags return flags to EvrynetNode
 func NewEvrynetNodeFlags() []cli.Flag {
 	return []cli.Flag{
-		cli.StringFlag{
+		cli.StringSliceFlag{
 			Name:  rpcEndpointFlag,
-			Usage: "RPC endpoint to send request",
-			Value: EvrynetEndpoint(),
+			Usage: "RPC endpoint to send request (http://, ws:// or IPC path), repeat the flag to spread requests over several nodes (default: \"" + EvrynetEndpoint() + "\")",
+		},
+		cli.StringFlag{
+			Name:  rpcPolicyFlag,
+			Usage: "How requests are spread over several RPC endpoints: round-robin, sticky, random or least-latency",
+			Value: string(RoundRobin),
 		}}
 }
 
-// NewEvrynetClientFromFlags returns Evrynet client from flag variable, or error if occurs
+// EndpointsFromFlags returns the RPC endpoints from flag variable
+func EndpointsFromFlags(ctx *cli.Context) []string {
+	endpoints := ctx.StringSlice(rpcEndpointFlag)
+	if len(endpoints) == 0 {
+		return []string{EvrynetEndpoint()}
+	}
+	return endpoints
+}
+
+// EndpointFromFlags returns the first RPC endpoint from flag variable
+func EndpointFromFlags(ctx *cli.Context) string {
+	return EndpointsFromFlags(ctx)[0]
+}
+
+// NewEvrynetClientFromFlags returns Evrynet client of the first RPC endpoint from flag variable, or error if occurs
 func NewEvrynetClientFromFlags(ctx *cli.Context) (*evrclient.Client, error) {
-	evrynetClientURL := ctx.String(rpcEndpointFlag)
-	return evrclient.Dial(evrynetClientURL)
+	return evrclient.Dial(EndpointFromFlags(ctx))
+}
+
+// NewPoolFromFlags returns a pool of every RPC endpoint from flag variable, or error if occurs
+func NewPoolFromFlags(ctx *cli.Context) (*Pool, error) {
+	policy, err := ParsePolicy(ctx.String(rpcPolicyFlag))
+	if err != nil {
+		return nil, err
+	}
+	return DialPool(EndpointsFromFlags(ctx), policy)
+}
+
+// PolicyFromFlags returns the load-balancing policy from flag variable
+func PolicyFromFlags(ctx *cli.Context) string {
+	return ctx.String(rpcPolicyFlag)
 }
@@ -0,0 +1,378 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/big"
+	"math/rand"
+	"net"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/evrclient"
+	"github.com/Evrynetlabs/evrynet-node/rpc"
+
+	"github.com/evrynet-official/evrynet-tools/lib/metrics"
+)
+
+// Policy decides which endpoint of a Pool serves a request
+type Policy string
+
+const (
+	// RoundRobin sends each request to the next endpoint
+	RoundRobin Policy = "round-robin"
+	// Sticky sends every request of an account to the same endpoint
+	Sticky Policy = "sticky"
+	// Random sends each request to a random endpoint
+	Random Policy = "random"
+	// LeastLatency sends each request to the endpoint with the lowest average latency
+	LeastLatency Policy = "least-latency"
+)
+
+// errorLatency is recorded as the latency of a request failed because the endpoint is unreachable,
+// so that LeastLatency stays away from it for a while.
+const errorLatency = time.Second
+
+// ParsePolicy returns the Policy named s
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(s); p {
+	case RoundRobin, Sticky, Random, LeastLatency:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown load-balancing policy %s", s)
+}
+
+// Endpoint is a node in a Pool
+type Endpoint struct {
+	URL    string
+	Client *evrclient.Client
+	// RPC is the connection of Client, for the methods evrclient does not wrap
+	RPC *rpc.Client
+
+	requests  uint64
+	errors    uint64
+	failovers uint64
+	// latency is the moving average latency in nanoseconds
+	latency int64
+}
+
+// EndpointStats are the counters of an endpoint
+type EndpointStats struct {
+	URL        string        `json:"url"`
+	Requests   uint64        `json:"requests"`
+	Errors     uint64        `json:"errors"`
+	Failovers  uint64        `json:"failovers"`
+	AvgLatency time.Duration `json:"avg_latency"`
+}
+
+func (e *Endpoint) record(elapsed time.Duration, err error) {
+	atomic.AddUint64(&e.requests, 1)
+	if isEndpointError(err) {
+		atomic.AddUint64(&e.errors, 1)
+		elapsed = errorLatency
+	}
+	for {
+		old := atomic.LoadInt64(&e.latency)
+		avg := int64(elapsed)
+		if old != 0 {
+			avg = old - old/8 + int64(elapsed)/8
+		}
+		if atomic.CompareAndSwapInt64(&e.latency, old, avg) {
+			return
+		}
+	}
+}
+
+// Pool spreads requests over several nodes and fails over to the next node when one is unreachable
+type Pool struct {
+	endpoints []*Endpoint
+	policy    Policy
+	next      uint64
+	mu        *sync.Mutex
+	rand      *rand.Rand
+}
+
+// DialPool connects to every url and returns a pool using policy
+func DialPool(urls []string, policy Policy) (*Pool, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("no RPC endpoint")
+	}
+	var endpoints []*Endpoint
+	for _, url := range urls {
+		rpcClient, err := rpc.Dial(url)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to dial %s", url)
+		}
+		endpoints = append(endpoints, &Endpoint{URL: url, Client: evrclient.NewClient(rpcClient), RPC: rpcClient})
+	}
+	return newPool(endpoints, policy), nil
+}
+
+// NewClientPool returns a pool of a single client
+func NewClientPool(client *evrclient.Client) *Pool {
+	return newPool([]*Endpoint{{URL: "client", Client: client}}, RoundRobin)
+}
+
+func newPool(endpoints []*Endpoint, policy Policy) *Pool {
+	return &Pool{
+		endpoints: endpoints,
+		policy:    policy,
+		mu:        &sync.Mutex{},
+		rand:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Endpoints returns the endpoints of the pool
+func (p *Pool) Endpoints() []*Endpoint {
+	return p.endpoints
+}
+
+// Sticky reports whether the requests about an account, and the txs it sends, go to the same endpoint
+func (p *Pool) Sticky() bool {
+	return p.policy == Sticky || len(p.endpoints) == 1
+}
+
+// pick returns the index of the endpoint to try first for a request about key
+func (p *Pool) pick(key []byte) int {
+	n := len(p.endpoints)
+	if n == 1 {
+		return 0
+	}
+	switch p.policy {
+	case Sticky:
+		if key != nil {
+			h := fnv.New32a()
+			_, _ = h.Write(key)
+			return int(h.Sum32() % uint32(n))
+		}
+	case Random:
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return p.rand.Intn(n)
+	case LeastLatency:
+		best := 0
+		for i, e := range p.endpoints {
+			if atomic.LoadInt64(&e.latency) < atomic.LoadInt64(&p.endpoints[best].latency) {
+				best = i
+			}
+		}
+		return best
+	}
+	return int((atomic.AddUint64(&p.next, 1) - 1) % uint64(n))
+}
+
+// SubscribeNewHeads sends the header of every new block of one of the endpoints to ch until the subscription
+// is cancelled, see SubscribeNewHeads
+func (p *Pool) SubscribeNewHeads(interval time.Duration, ch chan<- *types.Header) evrynet.Subscription {
+	return SubscribeNewHeads(p.endpoints[p.pick(nil)].Client, interval, ch)
+}
+
+// Do calls fn with the client picked for key, and with the next clients while the endpoint is unreachable.
+// method names the request in the latency metrics.
+func (p *Pool) Do(method string, key []byte, fn func(client *evrclient.Client) error) error {
+	return p.do(method, key, func(e *Endpoint) error {
+		return fn(e.Client)
+	})
+}
+
+// CallContext performs a JSON-RPC call on the endpoint picked for key, with the failover of Do
+func (p *Pool) CallContext(ctx context.Context, key []byte, result interface{}, method string, args ...interface{}) error {
+	return p.do(method, key, func(e *Endpoint) error {
+		if e.RPC == nil {
+			return fmt.Errorf("no RPC connection to %s", e.URL)
+		}
+		return e.RPC.CallContext(ctx, result, method, args...)
+	})
+}
+
+func (p *Pool) do(method string, key []byte, fn func(e *Endpoint) error) error {
+	var (
+		n     = len(p.endpoints)
+		first = p.pick(key)
+		err   error
+	)
+	for i := 0; i < n; i++ {
+		e := p.endpoints[(first+i)%n]
+		start := time.Now()
+		err = fn(e)
+		elapsed := time.Since(start)
+		e.record(elapsed, err)
+		metrics.ObserveRPC(e.URL, method, elapsed)
+		if !isEndpointError(err) || (isWrite(method) && !isDialError(err)) {
+			return err
+		}
+		if i < n-1 {
+			atomic.AddUint64(&e.failovers, 1)
+		}
+	}
+	return err
+}
+
+// isEndpointError reports whether err means the node could not serve the request,
+// as opposed to the node answering with an error.
+func isEndpointError(err error) bool {
+	if err == nil || err == evrynet.NotFound || err == context.Canceled {
+		return false
+	}
+	if _, ok := err.(rpc.Error); ok {
+		return false
+	}
+	return true
+}
+
+// isWrite reports whether a method changes the state of the node, so that it can not be sent again to
+// another endpoint once the node may have received it
+func isWrite(method string) bool {
+	return method == "eth_sendRawTransaction"
+}
+
+// isDialError reports whether err happened before the request reached the node, like a refused connection
+func isDialError(err error) bool {
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+	opErr, ok := err.(*net.OpError)
+	return ok && opErr.Op == "dial"
+}
+
+// Stats returns the counters of every endpoint
+func (p *Pool) Stats() []EndpointStats {
+	var stats []EndpointStats
+	for _, e := range p.endpoints {
+		stats = append(stats, EndpointStats{
+			URL:        e.URL,
+			Requests:   atomic.LoadUint64(&e.requests),
+			Errors:     atomic.LoadUint64(&e.errors),
+			Failovers:  atomic.LoadUint64(&e.failovers),
+			AvgLatency: time.Duration(atomic.LoadInt64(&e.latency)),
+		})
+	}
+	return stats
+}
+
+// PrintStats prints the counters of every endpoint on console view
+func (p *Pool) PrintStats() {
+	PrintEndpointStats(p.policy, p.Stats())
+}
+
+// PrintEndpointStats prints the counters of endpoints on console view
+func PrintEndpointStats(policy Policy, stats []EndpointStats) {
+	fmt.Printf("-----------Endpoint Stats (%s)----------------\n", policy)
+	for _, s := range stats {
+		fmt.Printf("%s: requests %d, errors %d, failovers %d, avg latency %s\n", s.URL, s.Requests, s.Errors, s.Failovers, s.AvgLatency)
+	}
+}
+
+// TxSender recovers the sender of a signed tx, with or without replay protection
+func TxSender(tx *types.Transaction) (common.Address, error) {
+	var signer types.Signer = types.HomesteadSigner{}
+	if tx.Protected() {
+		signer = types.NewEIP155Signer(tx.ChainId())
+	}
+	return types.Sender(signer, tx)
+}
+
+// PendingNonceAt returns the account nonce of the given account in the pending state.
+func (p *Pool) PendingNonceAt(ctx context.Context, account common.Address) (nonce uint64, err error) {
+	err = p.Do("eth_getTransactionCount", account.Bytes(), func(c *evrclient.Client) error {
+		nonce, err = c.PendingNonceAt(ctx, account)
+		return err
+	})
+	return nonce, err
+}
+
+// BalanceAt returns the wei balance of the given account.
+func (p *Pool) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (balance *big.Int, err error) {
+	err = p.Do("eth_getBalance", account.Bytes(), func(c *evrclient.Client) error {
+		balance, err = c.BalanceAt(ctx, account, blockNumber)
+		return err
+	})
+	return balance, err
+}
+
+// SendTransaction injects a signed transaction into the pending pool for execution.
+// With the Sticky policy the transaction goes to the endpoint of its sender.
+func (p *Pool) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	var key []byte
+	if p.policy == Sticky {
+		if from, err := TxSender(tx); err == nil {
+			key = from.Bytes()
+		}
+	}
+	return p.Do("eth_sendRawTransaction", key, func(c *evrclient.Client) error {
+		return c.SendTransaction(ctx, tx)
+	})
+}
+
+// TransactionReceipt returns the receipt of a transaction by transaction hash.
+func (p *Pool) TransactionReceipt(ctx context.Context, hash common.Hash) (receipt *types.Receipt, err error) {
+	err = p.Do("eth_getTransactionReceipt", nil, func(c *evrclient.Client) error {
+		receipt, err = c.TransactionReceipt(ctx, hash)
+		return err
+	})
+	return receipt, err
+}
+
+// SuggestGasPrice retrieves the currently suggested gas price.
+func (p *Pool) SuggestGasPrice(ctx context.Context) (price *big.Int, err error) {
+	err = p.Do("eth_gasPrice", nil, func(c *evrclient.Client) error {
+		price, err = c.SuggestGasPrice(ctx)
+		return err
+	})
+	return price, err
+}
+
+// EstimateGas estimates the gas needed to execute a specific transaction.
+func (p *Pool) EstimateGas(ctx context.Context, msg evrynet.CallMsg) (gas uint64, err error) {
+	err = p.Do("eth_estimateGas", msg.From.Bytes(), func(c *evrclient.Client) error {
+		gas, err = c.EstimateGas(ctx, msg)
+		return err
+	})
+	return gas, err
+}
+
+// CallContract executes a message call transaction.
+func (p *Pool) CallContract(ctx context.Context, msg evrynet.CallMsg, blockNumber *big.Int) (output []byte, err error) {
+	err = p.Do("eth_call", msg.From.Bytes(), func(c *evrclient.Client) error {
+		output, err = c.CallContract(ctx, msg, blockNumber)
+		return err
+	})
+	return output, err
+}
+
+// HeaderByNumber returns a block header from the current canonical chain. If number is
+// nil, the latest known header is returned.
+func (p *Pool) HeaderByNumber(ctx context.Context, number *big.Int) (header *types.Header, err error) {
+	err = p.Do("eth_getBlockByNumber", nil, func(c *evrclient.Client) error {
+		header, err = c.HeaderByNumber(ctx, number)
+		return err
+	})
+	return header, err
+}
+
+// ChainID retrieves the current chain ID for transaction replay protection.
+func (p *Pool) ChainID(ctx context.Context) (id *big.Int, err error) {
+	err = p.Do("eth_chainId", nil, func(c *evrclient.Client) error {
+		id, err = c.ChainID(ctx)
+		return err
+	})
+	return id, err
+}
+
+// NonceAt returns the account nonce of the given account.
+// The block number can be nil, in which case the nonce is taken from the latest known block.
+func (p *Pool) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (nonce uint64, err error) {
+	err = p.Do("eth_getTransactionCount", account.Bytes(), func(c *evrclient.Client) error {
+		nonce, err = c.NonceAt(ctx, account, blockNumber)
+		return err
+	})
+	return nonce, err
+}
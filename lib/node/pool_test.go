@@ -0,0 +1,109 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+)
+
+// newNonceServer returns a JSON-RPC server answering every request with nonce
+func newNonceServer(nonce string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": nonce})
+	}))
+}
+
+func TestPool_RoundRobin(t *testing.T) {
+	s1, s2 := newNonceServer("0x1"), newNonceServer("0x2")
+	defer s1.Close()
+	defer s2.Close()
+
+	pool, err := DialPool([]string{s1.URL, s2.URL}, RoundRobin)
+	assert.NoError(t, err)
+	for i := 0; i < 4; i++ {
+		_, err := pool.PendingNonceAt(context.Background(), common.Address{})
+		assert.NoError(t, err)
+	}
+	for _, s := range pool.Stats() {
+		assert.Equal(t, uint64(2), s.Requests)
+	}
+}
+
+func TestPool_Sticky(t *testing.T) {
+	s1, s2 := newNonceServer("0x1"), newNonceServer("0x2")
+	defer s1.Close()
+	defer s2.Close()
+
+	pool, err := DialPool([]string{s1.URL, s2.URL}, Sticky)
+	assert.NoError(t, err)
+	addr := common.HexToAddress("0x1289709BFaE305Fb7BE040b710A97c97672068bE")
+	first, err := pool.PendingNonceAt(context.Background(), addr)
+	assert.NoError(t, err)
+	for i := 0; i < 4; i++ {
+		nonce, err := pool.PendingNonceAt(context.Background(), addr)
+		assert.NoError(t, err)
+		assert.Equal(t, first, nonce)
+	}
+}
+
+func TestPool_Failover(t *testing.T) {
+	down, up := newNonceServer("0x1"), newNonceServer("0x2")
+	defer up.Close()
+	down.Close()
+
+	for _, policy := range []Policy{RoundRobin, Sticky, Random, LeastLatency} {
+		pool, err := DialPool([]string{down.URL, up.URL}, policy)
+		assert.NoError(t, err)
+		for i := 0; i < 4; i++ {
+			nonce, err := pool.PendingNonceAt(context.Background(), common.Address{})
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(2), nonce)
+		}
+		stats := pool.Stats()
+		assert.Equal(t, stats[0].Errors, stats[0].Failovers)
+		assert.Equal(t, uint64(4), stats[1].Requests)
+	}
+}
+
+func TestPool_FailoverWrite(t *testing.T) {
+	var hits int
+	// drop closes the connection after reading the request, like a node that goes away after accepting a tx
+	drop := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			_ = conn.Close()
+		}
+	}))
+	defer drop.Close()
+	up := newNonceServer("0x2")
+	defer up.Close()
+
+	pool, err := DialPool([]string{drop.URL, up.URL}, RoundRobin)
+	assert.NoError(t, err)
+	var result string
+	err = pool.CallContext(context.Background(), nil, &result, "eth_sendRawTransaction", "0x")
+	assert.Error(t, err)
+	assert.Equal(t, 1, hits)
+	assert.Equal(t, uint64(0), pool.Stats()[1].Requests)
+
+	// a tx is sent to the next endpoint when the connection is refused
+	drop.Close()
+	for i := 0; i < 2; i++ {
+		err = pool.CallContext(context.Background(), nil, &result, "eth_sendRawTransaction", "0x")
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, uint64(2), pool.Stats()[1].Requests)
+	assert.Equal(t, 1, hits)
+}
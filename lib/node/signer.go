@@ -0,0 +1,109 @@
+package node
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+
+	"github.com/Evrynetlabs/evrynet-node/accounts/abi/bind"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+
+	"github.com/evrynet-official/evrynet-tools/lib/deployment"
+)
+
+const (
+	chainIDFlag      = "chain-id"
+	legacySignerFlag = "legacy-signer"
+)
+
+// ChainIDReader reads the chain id of a node
+type ChainIDReader interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+}
+
+// NewSignerFlags return flags to choose how txs are signed
+func NewSignerFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.Uint64Flag{
+			Name:  chainIDFlag,
+			Usage: "Chain id txs are signed for, 0 means the chain id of the deployment or of the node",
+			Value: 0,
+		},
+		cli.StringFlag{
+			Name:  deployment.Flag,
+			Usage: "Deployment (staging) whose chain id txs are signed for, instead of asking the node",
+		},
+		cli.BoolFlag{
+			Name:  legacySignerFlag,
+			Usage: "Sign txs without chain id, so they can be replayed on every network",
+		},
+	}
+}
+
+// ChainIDFromFlags returns the chain id set by --chain-id or --deployment, or nil if the node should be asked
+func ChainIDFromFlags(ctx *cli.Context) (*big.Int, error) {
+	if id := ctx.Uint64(chainIDFlag); id != 0 {
+		return new(big.Int).SetUint64(id), nil
+	}
+	if name := ctx.String(deployment.Flag); name != "" {
+		d, err := deployment.Parse(name)
+		if err != nil {
+			return nil, err
+		}
+		chainID, err := d.ChainID()
+		if err != nil {
+			return nil, errors.Wrapf(err, "set --%s or ask the node", chainIDFlag)
+		}
+		return chainID, nil
+	}
+	return nil, nil
+}
+
+// LegacySignerFromFlags returns whether txs are signed without chain id
+func LegacySignerFromFlags(ctx *cli.Context) bool {
+	return ctx.Bool(legacySignerFlag)
+}
+
+// SignerFromFlags returns the signer chosen by flags, reading the chain id from client when no flag sets it
+func SignerFromFlags(ctx *cli.Context, client ChainIDReader) (types.Signer, error) {
+	chainID, err := ChainIDFromFlags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewSigner(client, chainID, LegacySignerFromFlags(ctx))
+}
+
+// NewSigner returns the Homestead signer when legacy is set, or else an EIP-155 signer of chainID,
+// read from client when chainID is nil
+func NewSigner(client ChainIDReader, chainID *big.Int, legacy bool) (types.Signer, error) {
+	if legacy {
+		return types.HomesteadSigner{}, nil
+	}
+	if chainID == nil {
+		var err error
+		if chainID, err = client.ChainID(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	return types.NewEIP155Signer(chainID), nil
+}
+
+// NewKeyedTransactor returns transact options signing with key and signer,
+// as contract bindings always ask for a Homestead signature
+func NewKeyedTransactor(key *ecdsa.PrivateKey, signer types.Signer) *bind.TransactOpts {
+	keyAddr := crypto.PubkeyToAddress(key.PublicKey)
+	return &bind.TransactOpts{
+		From: keyAddr,
+		Signer: func(_ types.Signer, address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != keyAddr {
+				return nil, errors.New("not authorized to sign this account")
+			}
+			return types.SignTx(tx, signer, key)
+		},
+	}
+}
@@ -0,0 +1,53 @@
+package node
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+
+	"github.com/evrynet-official/evrynet-tools/lib/deployment"
+)
+
+func TestNewSigner(t *testing.T) {
+	s := newNonceServer("0x2")
+	defer s.Close()
+	pool, err := DialPool([]string{s.URL}, RoundRobin)
+	assert.NoError(t, err)
+
+	signer, err := NewSigner(pool, nil, true)
+	assert.NoError(t, err)
+	assert.Equal(t, types.HomesteadSigner{}, signer)
+
+	// the chain id is read from the node when not given
+	signer, err = NewSigner(pool, nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, types.NewEIP155Signer(big.NewInt(2)), signer)
+
+	chainID, err := deployment.TestNet.ChainID()
+	assert.NoError(t, err)
+	signer, err = NewSigner(pool, chainID, false)
+	assert.NoError(t, err)
+	assert.Equal(t, types.NewEIP155Signer(big.NewInt(15)), signer)
+
+	_, err = deployment.Mainnet.ChainID()
+	assert.Error(t, err)
+}
+
+func TestNewKeyedTransactor(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	opts := NewKeyedTransactor(key, types.NewEIP155Signer(big.NewInt(3)))
+
+	// contract bindings ask for a Homestead signature
+	tx, err := opts.Signer(types.HomesteadSigner{}, opts.From, types.NewTransaction(0, opts.From, big.NewInt(1), 21000, big.NewInt(1), nil))
+	assert.NoError(t, err)
+	assert.True(t, tx.Protected())
+	assert.Equal(t, int64(3), tx.ChainId().Int64())
+	from, err := TxSender(tx)
+	assert.NoError(t, err)
+	assert.Equal(t, opts.From, from)
+}
@@ -0,0 +1,168 @@
+package node
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/event"
+	"github.com/Evrynetlabs/evrynet-node/evrclient"
+)
+
+// DefaultPollInterval is how often the latest block is polled when the endpoint does not support subscriptions
+const DefaultPollInterval = time.Second
+
+// SubscribeNewHeads sends the header of every new block to ch until the subscription is cancelled.
+// It uses a newHeads subscription when the endpoint supports notifications (ws:// and IPC),
+// and polls the latest header every interval when it does not (http://) or when the subscription breaks.
+func SubscribeNewHeads(client *evrclient.Client, interval time.Duration, ch chan<- *types.Header) evrynet.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		var (
+			heads = make(chan *types.Header)
+			last  *big.Int
+		)
+		sub, err := client.SubscribeNewHead(context.Background(), heads)
+		if err == nil {
+			stopped := func() bool {
+				defer sub.Unsubscribe()
+				for {
+					select {
+					case head := <-heads:
+						select {
+						case ch <- head:
+							last = head.Number
+						case <-quit:
+							return true
+						}
+					case <-sub.Err():
+						return false
+					case <-quit:
+						return true
+					}
+				}
+			}()
+			if stopped {
+				return nil
+			}
+		}
+		pollNewHeads(client, interval, last, ch, quit)
+		return nil
+	})
+}
+
+// pollNewHeads sends every header after last to ch, including the blocks mined between two polls.
+// When a block mined between two polls can not be fetched, the blocks from it are fetched again at the next poll.
+func pollNewHeads(client *evrclient.Client, interval time.Duration, last *big.Int, ch chan<- *types.Header, quit <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		head, err := client.HeaderByNumber(context.Background(), nil)
+		if err == nil && (last == nil || head.Number.Cmp(last) > 0) {
+			complete := true
+			if last != nil {
+				for n := new(big.Int).Add(last, common.Big1); n.Cmp(head.Number) < 0; n = new(big.Int).Add(n, common.Big1) {
+					missed, err := client.HeaderByNumber(context.Background(), n)
+					if err != nil {
+						complete = false
+						break
+					}
+					select {
+					case ch <- missed:
+						last = missed.Number
+					case <-quit:
+						return
+					}
+				}
+			}
+			if complete {
+				select {
+				case ch <- head:
+					last = head.Number
+				case <-quit:
+					return
+				}
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-quit:
+			return
+		}
+	}
+}
+
+// SubscribeLogs sends the logs matching q to ch until the subscription is cancelled.
+// It uses a logs subscription when the endpoint supports notifications, and filters the logs
+// of the new blocks every interval when it does not or when the subscription breaks.
+// After a broken subscription the logs of the last delivered block may be sent again.
+func SubscribeLogs(client *evrclient.Client, q evrynet.FilterQuery, interval time.Duration, ch chan<- types.Log) evrynet.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		var (
+			logs = make(chan types.Log)
+			from = q.FromBlock
+		)
+		sub, err := client.SubscribeFilterLogs(context.Background(), q, logs)
+		if err == nil {
+			stopped := func() bool {
+				defer sub.Unsubscribe()
+				for {
+					select {
+					case log := <-logs:
+						select {
+						case ch <- log:
+							from = new(big.Int).SetUint64(log.BlockNumber)
+						case <-quit:
+							return true
+						}
+					case <-sub.Err():
+						return false
+					case <-quit:
+						return true
+					}
+				}
+			}()
+			if stopped {
+				return nil
+			}
+		}
+		pollLogs(client, q, from, interval, ch, quit)
+		return nil
+	})
+}
+
+// pollLogs sends the logs matching q from block from onwards to ch
+func pollLogs(client *evrclient.Client, q evrynet.FilterQuery, from *big.Int, interval time.Duration, ch chan<- types.Log, quit <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		head, err := client.HeaderByNumber(context.Background(), nil)
+		if err == nil {
+			if from == nil {
+				from = head.Number
+			}
+			if head.Number.Cmp(from) >= 0 {
+				query := q
+				query.FromBlock, query.ToBlock = from, head.Number
+				logs, err := client.FilterLogs(context.Background(), query)
+				if err == nil {
+					for _, log := range logs {
+						select {
+						case ch <- log:
+						case <-quit:
+							return
+						}
+					}
+					from = new(big.Int).Add(head.Number, common.Big1)
+				}
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-quit:
+			return
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/evrclient"
+	"github.com/Evrynetlabs/evrynet-node/rpc"
+)
+
+// testChain serves the eth_getBlockByNumber and newHeads subscription of a chain mined on demand
+type testChain struct {
+	mu       sync.Mutex
+	headers  []*types.Header
+	notifier []func(*types.Header)
+	// failures is the number of times fetching a block by number fails
+	failures map[int64]int
+}
+
+func (c *testChain) mine() {
+	c.mu.Lock()
+	header := &types.Header{Number: big.NewInt(int64(len(c.headers))), Difficulty: big.NewInt(1), Time: uint64(len(c.headers))}
+	c.headers = append(c.headers, header)
+	notifiers := c.notifier
+	c.mu.Unlock()
+	for _, notify := range notifiers {
+		notify(header)
+	}
+}
+
+func (c *testChain) GetBlockByNumber(number rpc.BlockNumber, _ bool) (*types.Header, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if number == rpc.LatestBlockNumber {
+		return c.headers[len(c.headers)-1], nil
+	}
+	if int(number) >= len(c.headers) {
+		return nil, nil
+	}
+	if c.failures[int64(number)] > 0 {
+		c.failures[int64(number)]--
+		return nil, errors.New("node is busy")
+	}
+	return c.headers[number], nil
+}
+
+func (c *testChain) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	c.mu.Lock()
+	c.notifier = append(c.notifier, func(header *types.Header) {
+		_ = notifier.Notify(sub.ID, header)
+	})
+	c.mu.Unlock()
+	return sub, nil
+}
+
+func newTestChain(t *testing.T) (*testChain, *rpc.Server) {
+	chain := &testChain{failures: make(map[int64]int)}
+	chain.mine()
+	server := rpc.NewServer()
+	assert.NoError(t, server.RegisterName("eth", chain))
+	return chain, server
+}
+
+func receiveHeads(t *testing.T, heads chan *types.Header, numbers ...int64) {
+	for _, number := range numbers {
+		select {
+		case head := <-heads:
+			assert.Equal(t, number, head.Number.Int64())
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for block %d", number)
+		}
+	}
+}
+
+func TestSubscribeNewHeads_Push(t *testing.T) {
+	chain, server := newTestChain(t)
+	client := evrclient.NewClient(rpc.DialInProc(server))
+	defer client.Close()
+
+	heads := make(chan *types.Header)
+	// polling every hour would time out the test, so heads must be pushed
+	sub := SubscribeNewHeads(client, time.Hour, heads)
+	defer sub.Unsubscribe()
+
+	// wait for the subscription to be registered
+	for registered := false; !registered; {
+		chain.mu.Lock()
+		registered = len(chain.notifier) > 0
+		chain.mu.Unlock()
+		time.Sleep(time.Millisecond)
+	}
+	go func() {
+		chain.mine()
+		chain.mine()
+	}()
+	receiveHeads(t, heads, 1, 2)
+}
+
+func TestSubscribeNewHeads_Poll(t *testing.T) {
+	chain, server := newTestChain(t)
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+	client, err := evrclient.Dial(httpServer.URL)
+	assert.NoError(t, err)
+
+	heads := make(chan *types.Header)
+	sub := SubscribeNewHeads(client, 10*time.Millisecond, heads)
+	defer sub.Unsubscribe()
+
+	receiveHeads(t, heads, 0)
+	// blocks mined between two polls are not skipped
+	chain.mine()
+	chain.mine()
+	chain.mine()
+	receiveHeads(t, heads, 1, 2, 3)
+
+	// a block that can not be fetched is asked for again instead of being skipped
+	chain.mu.Lock()
+	chain.failures[5] = 2
+	chain.mu.Unlock()
+	chain.mine()
+	chain.mine()
+	chain.mine()
+	receiveHeads(t, heads, 4, 5, 6)
+}
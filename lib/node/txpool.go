@@ -0,0 +1,39 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+)
+
+// TxpoolStatus is the number of txs in the txpool of a node
+type TxpoolStatus struct {
+	Pending hexutil.Uint `json:"pending"`
+	Queued  hexutil.Uint `json:"queued"`
+}
+
+// TxpoolStatus returns the number of pending and queued txs of the node picked for key
+func (p *Pool) TxpoolStatus(ctx context.Context, key []byte) (status TxpoolStatus, err error) {
+	err = p.CallContext(ctx, key, &status, "txpool_status")
+	return status, err
+}
+
+// TxpoolContentOf returns the number of pending and queued txs of account in the txpool of the node picked for key
+func (p *Pool) TxpoolContentOf(ctx context.Context, key []byte, account common.Address) (pending, queued int, err error) {
+	// txs by status, sender and nonce
+	var content map[string]map[string]map[string]json.RawMessage
+	if err := p.CallContext(ctx, key, &content, "txpool_content"); err != nil {
+		return 0, 0, err
+	}
+	count := func(txs map[string]map[string]json.RawMessage) int {
+		for addr, byNonce := range txs {
+			if common.HexToAddress(addr) == account {
+				return len(byNonce)
+			}
+		}
+		return 0
+	}
+	return count(content["pending"]), count(content["queued"]), nil
+}
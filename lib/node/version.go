@@ -0,0 +1,19 @@
+package node
+
+import (
+	"context"
+
+	"github.com/Evrynetlabs/evrynet-node/rpc"
+)
+
+// ClientVersion returns the version of the node at url
+func ClientVersion(ctx context.Context, url string) (string, error) {
+	client, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+	var version string
+	err = client.CallContext(ctx, &version, "web3_clientVersion")
+	return version, err
+}
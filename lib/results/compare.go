@@ -0,0 +1,84 @@
+package results
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Thresholds are the largest changes from a baseline, in percent, that are not regressions
+type Thresholds struct {
+	TPSDrop       float64
+	LatencyRise   float64
+	BlockTimeRise float64
+}
+
+// Diff is the change of a metric from a baseline
+type Diff struct {
+	Metric   string
+	Baseline float64
+	Current  float64
+	// Change is the change from the baseline in percent
+	Change    float64
+	Regressed bool
+}
+
+// Compare returns the change of every metric recorded by both runs, sorted by metric.
+// A change from a baseline of 0 is infinite, so any rise of latency or block time from 0 is a regression.
+func Compare(baseline, run *Run, th Thresholds) []Diff {
+	var diffs []Diff
+	for metric, base := range baseline.Metrics {
+		current, ok := run.Metrics[metric]
+		if !ok {
+			continue
+		}
+		d := Diff{Metric: metric, Baseline: base, Current: current}
+		switch {
+		case base != 0:
+			d.Change = (current - base) / math.Abs(base) * 100
+		case current > 0:
+			d.Change = math.Inf(1)
+		case current < 0:
+			d.Change = math.Inf(-1)
+		}
+		switch metric {
+		case TPS:
+			d.Regressed = -d.Change > th.TPSDrop
+		case Latency, ConfirmationTime:
+			d.Regressed = d.Change > th.LatencyRise
+		case BlockTime:
+			d.Regressed = d.Change > th.BlockTimeRise
+		}
+		diffs = append(diffs, d)
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Metric < diffs[j].Metric })
+	return diffs
+}
+
+// Regressions returns the diffs beyond their threshold
+func Regressions(diffs []Diff) []Diff {
+	var regressed []Diff
+	for _, d := range diffs {
+		if d.Regressed {
+			regressed = append(regressed, d)
+		}
+	}
+	return regressed
+}
+
+// PrintComparison prints the diffs of run against baseline on console view
+func PrintComparison(baseline, run *Run, diffs []Diff) {
+	fmt.Println("-----------Comparison Stats----------------")
+	fmt.Printf("Baseline: %s (%s, commit %s, node %s)\n", baseline.ID, baseline.Label, baseline.Commit, baseline.NodeVersion)
+	fmt.Printf("Run: %s (%s, commit %s, node %s)\n", run.ID, run.Label, run.Commit, run.NodeVersion)
+	for _, d := range diffs {
+		status := "ok"
+		if d.Regressed {
+			status = "REGRESSION"
+		}
+		fmt.Printf("%s: %.4f => %.4f (%+.2f%%) %s\n", d.Metric, d.Baseline, d.Current, d.Change, status)
+	}
+	if run.Error != "" {
+		fmt.Println("Run error:", run.Error)
+	}
+}
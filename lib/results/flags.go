@@ -0,0 +1,149 @@
+package results
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/evrynet-official/evrynet-tools/lib/node"
+)
+
+const (
+	resultsFlag        = "results"
+	labelFlag          = "label"
+	baselineFlag       = "baseline"
+	runFlag            = "run"
+	maxTPSDropFlag     = "max-tps-drop"
+	maxLatencyFlag     = "max-latency-rise"
+	maxBlockTimeFlag   = "max-block-time-rise"
+	nodeVersionTimeout = 5 * time.Second
+)
+
+// NewResultsFlags return flags to save the run of a tool
+func NewResultsFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  resultsFlag,
+			Usage: "JSON-lines file the run is appended to, disabled when empty",
+		},
+		cli.StringFlag{
+			Name:  labelFlag,
+			Usage: "Name of the run, to use it as a baseline of compare",
+		},
+	}
+}
+
+// SaveFromFlags records the flags and the node version in run and appends it to the results file of flags
+func SaveFromFlags(ctx *cli.Context, run *Run) error {
+	path := ctx.String(resultsFlag)
+	if path == "" {
+		return nil
+	}
+	run.Label = ctx.String(labelFlag)
+	for _, name := range ctx.GlobalFlagNames() {
+		setParam(run, name, ctx.GlobalGeneric(name))
+	}
+	for _, name := range ctx.FlagNames() {
+		setParam(run, name, ctx.Generic(name))
+	}
+
+	c, cancel := context.WithTimeout(context.Background(), nodeVersionTimeout)
+	defer cancel()
+	version, err := node.ClientVersion(c, node.EndpointFromFlags(ctx))
+	if err != nil {
+		log.Printf("failed to get node version: %s", err)
+	}
+	run.NodeVersion = version
+
+	store := &Store{Path: path}
+	if err := store.Save(run); err != nil {
+		return err
+	}
+	fmt.Printf("Run %s saved to %s\n", run.ID, path)
+	return nil
+}
+
+// setParam records the value of a flag, leaving out the flags holding secrets whatever the case of their name.
+// The account seed is a secret too, the private keys of the accounts are derived from it.
+func setParam(run *Run, name string, value interface{}) {
+	lower := strings.ToLower(name)
+	if value == nil || strings.HasSuffix(lower, "pk") || strings.Contains(lower, "key") ||
+		strings.Contains(lower, "token") || strings.Contains(lower, "password") || lower == "seed" {
+		return
+	}
+	run.Params[name] = fmt.Sprint(value)
+}
+
+// NewCompareFlags return flags to compare a run against a baseline
+func NewCompareFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  resultsFlag,
+			Usage: "JSON-lines file of the runs",
+			Value: "results.jsonl",
+		},
+		cli.StringFlag{
+			Name:  baselineFlag,
+			Usage: "Label or id of the baseline run",
+		},
+		cli.StringFlag{
+			Name:  runFlag,
+			Usage: "Label or id of the run to compare, latest for the last saved run",
+			Value: LatestRun,
+		},
+		cli.Float64Flag{
+			Name:  maxTPSDropFlag,
+			Usage: "Largest drop of TPS from the baseline, in percent, that is not a regression",
+			Value: 10,
+		},
+		cli.Float64Flag{
+			Name:  maxLatencyFlag,
+			Usage: "Largest rise of latency or confirmation time from the baseline, in percent, that is not a regression",
+			Value: 10,
+		},
+		cli.Float64Flag{
+			Name:  maxBlockTimeFlag,
+			Usage: "Largest rise of block time from the baseline, in percent, that is not a regression",
+			Value: 10,
+		},
+	}
+}
+
+// CompareFromFlags compares the run of flags against the baseline and returns an error when a metric regressed
+func CompareFromFlags(ctx *cli.Context) error {
+	if ctx.String(baselineFlag) == "" {
+		return fmt.Errorf("--%s is required", baselineFlag)
+	}
+	store := &Store{Path: ctx.String(resultsFlag)}
+	run, err := store.Find(ctx.String(runFlag))
+	if err != nil {
+		return err
+	}
+	// the metrics of different tools measure different things
+	baseline, err := store.FindOf(ctx.String(baselineFlag), run.Tool)
+	if err != nil {
+		return err
+	}
+
+	diffs := Compare(baseline, run, Thresholds{
+		TPSDrop:       ctx.Float64(maxTPSDropFlag),
+		LatencyRise:   ctx.Float64(maxLatencyFlag),
+		BlockTimeRise: ctx.Float64(maxBlockTimeFlag),
+	})
+	PrintComparison(baseline, run, diffs)
+	if run.Error != "" {
+		return fmt.Errorf("run %s failed: %s", run.ID, run.Error)
+	}
+	if regressed := Regressions(diffs); len(regressed) != 0 {
+		var metrics []string
+		for _, d := range regressed {
+			metrics = append(metrics, d.Metric)
+		}
+		return fmt.Errorf("%s regressed against %s", strings.Join(metrics, ", "), baseline.ID)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package results
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Names of the metrics compared against a baseline, tools may record other metrics
+const (
+	// TPS is the number of txs per second, higher is better
+	TPS = "tps"
+	// Latency is the average RPC latency in milliseconds, lower is better
+	Latency = "latency_ms"
+	// ConfirmationTime is the average time from sending a tx to its receipt in milliseconds, lower is better
+	ConfirmationTime = "confirmation_ms"
+	// BlockTime is the average time between blocks in seconds, lower is better
+	BlockTime = "block_time_s"
+)
+
+// LatestRun refers to the last run of a store
+const LatestRun = "latest"
+
+// Commit is the git commit the tools were built from, set by the Makefile
+var Commit = "unknown"
+
+// Run is the record of one run of a tool
+type Run struct {
+	ID          string             `json:"id"`
+	Label       string             `json:"label,omitempty"`
+	Tool        string             `json:"tool"`
+	Time        time.Time          `json:"time"`
+	Commit      string             `json:"commit"`
+	NodeVersion string             `json:"node_version,omitempty"`
+	Params      map[string]string  `json:"params,omitempty"`
+	Metrics     map[string]float64 `json:"metrics"`
+	// Error is the error the run ended with, if any
+	Error string `json:"error,omitempty"`
+}
+
+// NewRun returns a run of tool started now
+func NewRun(tool string) *Run {
+	now := time.Now().UTC()
+	return &Run{
+		ID:      fmt.Sprintf("%s-%s", tool, now.Format("20060102T150405.000")),
+		Tool:    tool,
+		Time:    now,
+		Commit:  Commit,
+		Params:  make(map[string]string),
+		Metrics: make(map[string]float64),
+	}
+}
+
+// Store keeps runs as one JSON object per line of a file
+type Store struct {
+	Path string
+}
+
+// Save appends run to the store
+func (s *Store) Save(run *Run) error {
+	file, err := os.OpenFile(s.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	line, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(file, string(line))
+	return err
+}
+
+// Runs returns the runs of the store in the order they were saved
+func (s *Store) Runs() ([]*Run, error) {
+	file, err := os.Open(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadRuns(file)
+}
+
+// Find returns the last run whose id or label is ref, or the last run of the store for LatestRun
+func (s *Store) Find(ref string) (*Run, error) {
+	return s.FindOf(ref, "")
+}
+
+// FindOf returns the last run of tool with id or label ref, or the last run of tool for LatestRun.
+// Runs of every tool are looked up when tool is empty.
+func (s *Store) FindOf(ref string, tool string) (*Run, error) {
+	runs, err := s.Runs()
+	if err != nil {
+		return nil, err
+	}
+	for i := len(runs) - 1; i >= 0; i-- {
+		if tool != "" && runs[i].Tool != tool {
+			continue
+		}
+		if ref == LatestRun || runs[i].ID == ref || runs[i].Label == ref {
+			return runs[i], nil
+		}
+	}
+	if tool != "" {
+		return nil, fmt.Errorf("no run %s of %s in %s", ref, tool, s.Path)
+	}
+	return nil, fmt.Errorf("no run %s in %s", ref, s.Path)
+}
+
+// ReadRuns reads runs written one per line
+func ReadRuns(r io.Reader) ([]*Run, error) {
+	var (
+		runs    []*Run
+		scanner = bufio.NewScanner(r)
+		line    int
+	)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		run := new(Run)
+		if err := json.Unmarshal([]byte(text), run); err != nil {
+			return nil, errors.Wrapf(err, "invalid run at line %d", line)
+		}
+		runs = append(runs, run)
+	}
+	return runs, scanner.Err()
+}
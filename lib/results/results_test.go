@@ -0,0 +1,81 @@
+package results
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_Find(t *testing.T) {
+	dir, err := ioutil.TempDir("", "results")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	store := &Store{Path: filepath.Join(dir, "results.jsonl")}
+
+	baseline := NewRun("tx_metric byblock")
+	baseline.Label = "v1"
+	baseline.Metrics[TPS] = 100
+	require.NoError(t, store.Save(baseline))
+	run := NewRun("tx_metric byblock")
+	run.ID += "-2"
+	run.Metrics[TPS] = 80
+	require.NoError(t, store.Save(run))
+
+	found, err := store.Find("v1")
+	require.NoError(t, err)
+	assert.Equal(t, baseline.ID, found.ID)
+	assert.Equal(t, float64(100), found.Metrics[TPS])
+	found, err = store.Find(LatestRun)
+	require.NoError(t, err)
+	assert.Equal(t, run.ID, found.ID)
+	_, err = store.Find("v2")
+	assert.Error(t, err)
+
+	// the baseline of a run is a run of the same tool
+	other := NewRun("tx_flood")
+	other.Label = "v1"
+	require.NoError(t, store.Save(other))
+	found, err = store.FindOf("v1", "tx_metric byblock")
+	require.NoError(t, err)
+	assert.Equal(t, baseline.ID, found.ID)
+	_, err = store.FindOf("v1", "stress_sc stressvotes")
+	assert.Error(t, err)
+}
+
+func TestSetParam(t *testing.T) {
+	run := NewRun("blockmonitor")
+	for _, name := range []string{"senderpk", "apiToken", "smtp-Password", "privateKey", "seed", "rpcendpoint", "rand-seed"} {
+		setParam(run, name, "secret")
+	}
+	assert.Equal(t, map[string]string{"rpcendpoint": "secret", "rand-seed": "secret"}, run.Params)
+}
+
+func TestCompare(t *testing.T) {
+	baseline := &Run{Metrics: map[string]float64{TPS: 100, Latency: 20, BlockTime: 1, "sent": 1000}}
+	run := &Run{Metrics: map[string]float64{TPS: 85, Latency: 21, BlockTime: 1.5, "sent": 500}}
+
+	diffs := Compare(baseline, run, Thresholds{TPSDrop: 10, LatencyRise: 10, BlockTimeRise: 10})
+	require.Len(t, diffs, 4)
+	assert.Equal(t, BlockTime, diffs[0].Metric)
+	assert.InDelta(t, 50, diffs[0].Change, 1e-9)
+
+	var regressed []string
+	for _, d := range Regressions(diffs) {
+		regressed = append(regressed, d.Metric)
+	}
+	// latency rose by 5% only, and sent is not gated
+	assert.Equal(t, []string{BlockTime, TPS}, regressed)
+
+	// any rise from a baseline of 0 is a regression
+	baseline = &Run{Metrics: map[string]float64{TPS: 0, ConfirmationTime: 0, BlockTime: 0}}
+	run = &Run{Metrics: map[string]float64{TPS: 10, ConfirmationTime: 5, BlockTime: 0}}
+	regressed = nil
+	for _, d := range Regressions(Compare(baseline, run, Thresholds{TPSDrop: 10, LatencyRise: 10, BlockTimeRise: 10})) {
+		regressed = append(regressed, d.Metric)
+	}
+	assert.Equal(t, []string{ConfirmationTime}, regressed)
+}
@@ -9,8 +9,14 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/evrclient"
+
+	"github.com/evrynet-official/evrynet-tools/lib/node"
 )
 
+// receiptCheckInterval is how often the receipt is checked when no new block comes,
+// so that the error of a node going down is returned
+const receiptCheckInterval = 5 * time.Second
+
 // CheckTransStatus this function is blocking and returns status of a transaction
 func CheckTransStatus(client *evrclient.Client, tx *types.Transaction) error {
 	var err error
@@ -27,8 +33,17 @@ func CheckTransStatus(client *evrclient.Client, tx *types.Transaction) error {
 	return nil
 }
 
-// WaitForTx wait for a transaction is finished
+// WaitForTx wait for a transaction is finished, checking for its receipt at every new block
+// and every receiptCheckInterval
 func WaitForTx(client *evrclient.Client, hash common.Hash) (*types.Receipt, error) {
+	var (
+		heads  = make(chan *types.Header)
+		sub    = node.SubscribeNewHeads(client, node.DefaultPollInterval, heads)
+		subErr = sub.Err()
+		ticker = time.NewTicker(receiptCheckInterval)
+	)
+	defer sub.Unsubscribe()
+	defer ticker.Stop()
 	for {
 		receipt, err := client.TransactionReceipt(context.Background(), hash)
 		switch err {
@@ -38,6 +53,15 @@ func WaitForTx(client *evrclient.Client, hash common.Hash) (*types.Receipt, erro
 		default:
 			return receipt, err
 		}
-		time.Sleep(1 * time.Second)
+		select {
+		case <-heads:
+		case <-ticker.C:
+		case err := <-subErr:
+			if err != nil {
+				return nil, err
+			}
+			// no more heads, the ticker still checks the receipt
+			subErr, heads = nil, nil
+		}
 	}
 }
@@ -0,0 +1,47 @@
+package scenario
+
+import (
+	"os"
+
+	"github.com/urfave/cli"
+	"go.uber.org/zap"
+
+	"github.com/evrynet-official/evrynet-tools/lib/node"
+)
+
+const (
+	fileFlag        = "file"
+	rpcEndpointFlag = "rpcendpoint"
+)
+
+// NewScenarioFlags return flags to run a scenario
+func NewScenarioFlags() []cli.Flag {
+	flags := []cli.Flag{
+		cli.StringFlag{
+			Name:  fileFlag,
+			Usage: "YAML file of the scenario",
+			Value: "scenario.yaml",
+		},
+	}
+	return append(flags, node.NewEvrynetNodeFlags()...)
+}
+
+// NewRunnerFromFlags reads the scenario file of flags and returns its runner,
+// the RPC endpoints of flags replace those of the file when they are set
+func NewRunnerFromFlags(ctx *cli.Context, logger *zap.SugaredLogger) (*Runner, error) {
+	file, err := os.Open(ctx.String(fileFlag))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	s, err := Load(file)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.IsSet(rpcEndpointFlag) {
+		s.RPCEndpoints = node.EndpointsFromFlags(ctx)
+		s.RPCPolicy = node.PolicyFromFlags(ctx)
+	}
+	return NewRunner(s, logger)
+}
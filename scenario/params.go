@@ -0,0 +1,127 @@
+package scenario
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+)
+
+// stepParams reads the resolved params of a step, the first invalid param is kept in err
+type stepParams struct {
+	values map[string]string
+	err    error
+}
+
+func (p *stepParams) fail(name, value, kind string) {
+	if p.err == nil {
+		p.err = fmt.Errorf("param %s: %q is not %s", name, value, kind)
+	}
+}
+
+// String returns the param name or def when it is not set
+func (p *stepParams) String(name, def string) string {
+	if value, ok := p.values[name]; ok && value != "" {
+		return value
+	}
+	return def
+}
+
+// Required returns the param name and records an error when it is not set
+func (p *stepParams) Required(name string) string {
+	value := p.String(name, "")
+	if value == "" && p.err == nil {
+		p.err = fmt.Errorf("param %s is required", name)
+	}
+	return value
+}
+
+func (p *stepParams) Int(name string, def int) int {
+	value := p.String(name, "")
+	if value == "" {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		p.fail(name, value, "an integer")
+	}
+	return n
+}
+
+func (p *stepParams) Int64(name string, def int64) int64 {
+	value := p.String(name, "")
+	if value == "" {
+		return def
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		p.fail(name, value, "an integer")
+	}
+	return n
+}
+
+func (p *stepParams) Uint64(name string, def uint64) uint64 {
+	value := p.String(name, "")
+	if value == "" {
+		return def
+	}
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		p.fail(name, value, "a block number")
+	}
+	return n
+}
+
+func (p *stepParams) Float64(name string, def float64) float64 {
+	value := p.String(name, "")
+	if value == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		p.fail(name, value, "a number")
+	}
+	return f
+}
+
+func (p *stepParams) Duration(name string, def time.Duration) time.Duration {
+	value := p.String(name, "")
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		p.fail(name, value, "a duration")
+	}
+	return d
+}
+
+// Wei returns the param name as an amount of wei
+func (p *stepParams) Wei(name, def string) *big.Int {
+	value := p.String(name, def)
+	n, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		p.fail(name, value, "an amount of wei")
+		return new(big.Int)
+	}
+	return n
+}
+
+// Block returns the required param name as a block number
+func (p *stepParams) Block(name string) uint64 {
+	if p.Required(name) == "" {
+		return 0
+	}
+	return p.Uint64(name, 0)
+}
+
+// Address returns the required param name as an address
+func (p *stepParams) Address(name string) common.Address {
+	value := p.Required(name)
+	if value != "" && !common.IsHexAddress(value) {
+		p.fail(name, value, "an address")
+	}
+	return common.HexToAddress(value)
+}
@@ -0,0 +1,223 @@
+package scenario
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/evrclient"
+
+	"github.com/evrynet-official/evrynet-tools/accounts"
+	"github.com/evrynet-official/evrynet-tools/lib/node"
+)
+
+// Scenario is an ordered list of steps run against the same nodes
+type Scenario struct {
+	RPCEndpoints []string `yaml:"rpcendpoints"`
+	RPCPolicy    string   `yaml:"rpc_policy"`
+	// ChainID is the chain id txs are signed for, 0 means the chain id of the node
+	ChainID      uint64 `yaml:"chain_id"`
+	LegacySigner bool   `yaml:"legacy_signer"`
+	Steps        []Step `yaml:"steps"`
+}
+
+// Step is a step of a scenario. Params may refer to an output of a previous step as ${name.output}.
+type Step struct {
+	// Name is the name outputs are referred to by, the type when it is empty
+	Name   string            `yaml:"name"`
+	Type   string            `yaml:"type"`
+	Params map[string]string `yaml:"params"`
+}
+
+// Load reads a scenario from a YAML document
+func Load(r io.Reader) (*Scenario, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	s := &Scenario{}
+	if err := yaml.UnmarshalStrict(data, s); err != nil {
+		return nil, errors.Wrap(err, "invalid scenario")
+	}
+	names := make(map[string]bool)
+	for i := range s.Steps {
+		step := &s.Steps[i]
+		if step.Name == "" {
+			step.Name = step.Type
+		}
+		if _, ok := stepFuncs[step.Type]; !ok {
+			return nil, fmt.Errorf("step %d: unknown type %q", i+1, step.Type)
+		}
+		if names[step.Name] {
+			return nil, fmt.Errorf("step %d: name %q is used by another step", i+1, step.Name)
+		}
+		names[step.Name] = true
+	}
+	return s, nil
+}
+
+// stepFunc runs a step and returns its outputs
+type stepFunc func(r *Runner, p *stepParams) (map[string]string, error)
+
+// stepFuncs are the types of steps
+var stepFuncs = map[string]stepFunc{
+	"generate": generateStep,
+	"deposit":  depositStep,
+	"deploy":   deployStep,
+	"flood":    floodStep,
+	"vote":     voteStep,
+	"metrics":  metricsStep,
+	"assert":   assertStep,
+	"sweep":    sweepStep,
+}
+
+// StepResult is the outcome of a step that ran
+type StepResult struct {
+	Step    Step
+	Outputs map[string]string
+	Elapsed time.Duration
+	Err     error
+}
+
+// Runner runs the steps of a scenario in order
+type Runner struct {
+	Scenario *Scenario
+	Logger   *zap.SugaredLogger
+	// Results are the outcome of the steps that ran
+	Results []StepResult
+
+	pool     *node.Pool
+	client   *evrclient.Client
+	signer   types.Signer
+	accounts []*accounts.Account
+	outputs  map[string]map[string]string
+	steps    map[string]stepFunc
+}
+
+// NewRunner dials the nodes of the scenario and returns a runner of its steps
+func NewRunner(s *Scenario, logger *zap.SugaredLogger) (*Runner, error) {
+	if len(s.RPCEndpoints) == 0 {
+		s.RPCEndpoints = []string{node.EvrynetEndpoint()}
+	}
+	policy := node.RoundRobin
+	if s.RPCPolicy != "" {
+		var err error
+		if policy, err = node.ParsePolicy(s.RPCPolicy); err != nil {
+			return nil, err
+		}
+	}
+	pool, err := node.DialPool(s.RPCEndpoints, policy)
+	if err != nil {
+		return nil, err
+	}
+	client, err := evrclient.Dial(s.RPCEndpoints[0])
+	if err != nil {
+		return nil, err
+	}
+	var chainID *big.Int
+	if s.ChainID != 0 {
+		chainID = new(big.Int).SetUint64(s.ChainID)
+	}
+	signer, err := node.NewSigner(pool, chainID, s.LegacySigner)
+	if err != nil {
+		return nil, err
+	}
+	return &Runner{
+		Scenario: s,
+		Logger:   logger,
+		pool:     pool,
+		client:   client,
+		signer:   signer,
+		outputs:  make(map[string]map[string]string),
+		steps:    stepFuncs,
+	}, nil
+}
+
+// Run runs every step in order and stops at the first step that fails
+func (r *Runner) Run() error {
+	for i, step := range r.Scenario.Steps {
+		fmt.Printf("--- Step %d/%d: %s (%s)\n", i+1, len(r.Scenario.Steps), step.Name, step.Type)
+		var (
+			start  = time.Now()
+			result = StepResult{Step: step}
+		)
+		values, err := r.resolve(step.Params)
+		if err == nil {
+			p := &stepParams{values: values}
+			result.Outputs, err = r.steps[step.Type](r, p)
+			if err == nil {
+				err = p.err
+			}
+		}
+		result.Elapsed = time.Since(start)
+		result.Err = err
+		r.Results = append(r.Results, result)
+		if err != nil {
+			return errors.Wrapf(err, "step %s failed", step.Name)
+		}
+		r.outputs[step.Name] = result.Outputs
+	}
+	return nil
+}
+
+// refPattern matches a reference to the output of a step
+var refPattern = regexp.MustCompile(`\$\{([\w-]+)\.([\w-]+)\}`)
+
+// resolve replaces the references to outputs of previous steps in params
+func (r *Runner) resolve(raw map[string]string) (map[string]string, error) {
+	var (
+		values = make(map[string]string, len(raw))
+		err    error
+	)
+	for name, value := range raw {
+		values[name] = refPattern.ReplaceAllStringFunc(value, func(ref string) string {
+			m := refPattern.FindStringSubmatch(ref)
+			out, ok := r.output(m[1], m[2])
+			if !ok && err == nil {
+				err = fmt.Errorf("param %s refers to %s, which no previous step output", name, ref)
+			}
+			return out
+		})
+	}
+	return values, err
+}
+
+// output returns the output of a previous step
+func (r *Runner) output(step, name string) (string, bool) {
+	out, ok := r.outputs[step][name]
+	return out, ok
+}
+
+// Print prints the outputs of every step on console view
+func (r *Runner) Print() {
+	fmt.Println("-----------Scenario Stats----------------")
+	for _, res := range r.Results {
+		status := "ok"
+		if res.Err != nil {
+			status = "failed: " + res.Err.Error()
+		}
+		fmt.Printf("%s (%s): %s in %s\n", res.Step.Name, res.Step.Type, status, res.Elapsed)
+		var names []string
+		for name := range res.Outputs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var outputs []string
+		for _, name := range names {
+			outputs = append(outputs, fmt.Sprintf("%s=%s", name, res.Outputs[name]))
+		}
+		if len(outputs) != 0 {
+			fmt.Println("  " + strings.Join(outputs, " "))
+		}
+	}
+}
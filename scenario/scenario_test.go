@@ -0,0 +1,112 @@
+package scenario
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testScenario = `
+steps:
+  - type: generate
+    params:
+      num: 10
+  - name: load
+    type: flood
+    params:
+      rate: 100
+  - type: metrics
+    params:
+      start_block: ${load.start_block}
+      end_block: ${load.end_block}
+  - type: assert
+    params:
+      load.failed: "== 0"
+      metrics.tps: ">= 50"
+`
+
+func TestLoad(t *testing.T) {
+	s, err := Load(strings.NewReader(testScenario))
+	require.NoError(t, err)
+	require.Len(t, s.Steps, 4)
+	assert.Equal(t, "generate", s.Steps[0].Name)
+	assert.Equal(t, "10", s.Steps[0].Params["num"])
+	assert.Equal(t, "load", s.Steps[1].Name)
+
+	_, err = Load(strings.NewReader("steps:\n  - type: explode\n"))
+	assert.Error(t, err)
+	_, err = Load(strings.NewReader("steps:\n  - type: flood\n  - type: flood\n"))
+	assert.Error(t, err)
+	_, err = Load(strings.NewReader("step:\n  - type: flood\n"))
+	assert.Error(t, err)
+}
+
+func TestRunner_Run(t *testing.T) {
+	s, err := Load(strings.NewReader(testScenario))
+	require.NoError(t, err)
+
+	var metricsParams map[string]string
+	r := &Runner{
+		Scenario: s,
+		outputs:  make(map[string]map[string]string),
+		steps: map[string]stepFunc{
+			"generate": func(r *Runner, p *stepParams) (map[string]string, error) {
+				return map[string]string{"num": p.String("num", "")}, nil
+			},
+			"flood": func(r *Runner, p *stepParams) (map[string]string, error) {
+				return map[string]string{"start_block": "11", "end_block": "20", "failed": "0"}, nil
+			},
+			"metrics": func(r *Runner, p *stepParams) (map[string]string, error) {
+				metricsParams = p.values
+				return map[string]string{"tps": "42.5"}, nil
+			},
+			"assert": assertStep,
+		},
+	}
+	err = r.Run()
+	assert.EqualError(t, err, "step assert failed: assertions failed: metrics.tps = 42.5, expected >= 50")
+	assert.Equal(t, map[string]string{"start_block": "11", "end_block": "20"}, metricsParams)
+	require.Len(t, r.Results, 4)
+	assert.Equal(t, "1", r.Results[3].Outputs["failed"])
+
+	// a reference to a step that did not run fails the step
+	s.Steps = []Step{{Name: "metrics", Type: "metrics", Params: map[string]string{"start_block": "${flood.end_block}"}}}
+	r.Results, r.outputs = nil, make(map[string]map[string]string)
+	assert.Error(t, r.Run())
+
+	// metrics does not scan from genesis when start_block is missing
+	s.Steps = []Step{{Name: "metrics", Type: "metrics"}}
+	r.Results, r.outputs = nil, make(map[string]map[string]string)
+	r.steps["metrics"] = metricsStep
+	assert.EqualError(t, r.Run(), "step metrics failed: param start_block is required")
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		actual    string
+		condition string
+		pass      bool
+		err       bool
+	}{
+		{actual: "50", condition: ">= 50", pass: true},
+		{actual: "49.9", condition: ">=50", pass: false},
+		{actual: "0", condition: "== 0", pass: true},
+		{actual: "1.5", condition: "< 2", pass: true},
+		{actual: "2", condition: "> 2", pass: false},
+		{actual: "3", condition: "!= 3", pass: false},
+		{actual: "abc", condition: "== abc", pass: true},
+		{actual: "abc", condition: "> 1", err: true},
+		{actual: "1", condition: "~ 1", err: true},
+	}
+	for _, test := range tests {
+		pass, err := check(test.actual, test.condition)
+		if test.err {
+			assert.Error(t, err, "%s %s", test.actual, test.condition)
+			continue
+		}
+		require.NoError(t, err, "%s %s", test.actual, test.condition)
+		assert.Equal(t, test.pass, pass, "%s %s", test.actual, test.condition)
+	}
+}
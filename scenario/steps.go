@@ -0,0 +1,431 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	stakingContracts "github.com/Evrynetlabs/evrynet-node/consensus/staking_contracts"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+	"github.com/Evrynetlabs/evrynet-node/params"
+
+	"github.com/evrynet-official/evrynet-tools/accounts"
+	"github.com/evrynet-official/evrynet-tools/accounts/depositor"
+	"github.com/evrynet-official/evrynet-tools/lib/node"
+	"github.com/evrynet-official/evrynet-tools/lib/txutil"
+	sc "github.com/evrynet-official/evrynet-tools/stakingcontract"
+	"github.com/evrynet-official/evrynet-tools/tx_flood"
+	"github.com/evrynet-official/evrynet-tools/tx_metric"
+)
+
+var errNoAccounts = errors.New("no accounts, a generate step has to run first")
+
+// defaultInclusionTimeout is the time the txs of a flood have to be mined
+const defaultInclusionTimeout = 2 * time.Minute
+
+// generateStep generates the accounts used by the next steps
+func generateStep(r *Runner, p *stepParams) (map[string]string, error) {
+	var (
+		num    = p.Int("num", 4)
+		seed   = p.String("seed", "evrynet")
+		offset = p.Int("offset", 0)
+	)
+	if p.err != nil {
+		return nil, p.err
+	}
+	accs, err := accounts.GenerateAccountsFrom(offset, num, seed)
+	if err != nil {
+		return nil, err
+	}
+	r.accounts = accs
+	out := map[string]string{"num": strconv.Itoa(len(accs))}
+	if len(accs) != 0 {
+		out["first"] = accs[0].Address.Hex()
+	}
+	return out, nil
+}
+
+// depositStep tops up the balance of every account from senderpk
+func depositStep(r *Runner, p *stepParams) (map[string]string, error) {
+	var (
+		senderPk = p.Required("senderpk")
+		expected = p.Wei("expectedbalance", "1000000000000000000")
+		nCore    = p.Int("ncore", 100)
+	)
+	if p.err != nil {
+		return nil, p.err
+	}
+	if len(r.accounts) == 0 {
+		return nil, errNoAccounts
+	}
+	pk, err := crypto.HexToECDSA(senderPk)
+	if err != nil {
+		return nil, err
+	}
+	opt := node.NewKeyedTransactor(pk, r.signer)
+	dp := depositor.NewDepositor(r.Logger, opt, opt.From, r.accounts, r.pool, expected, nCore,
+		depositor.WithGasLimit(1000000), depositor.WithSigner(r.signer))
+	if err := dp.CheckAndDeposit(); err != nil {
+		return nil, err
+	}
+	return map[string]string{"accounts": strconv.Itoa(len(r.accounts))}, nil
+}
+
+// deployStep deploys the number contract called by the flood mode with SC from the first account
+func deployStep(r *Runner, p *stepParams) (map[string]string, error) {
+	if len(r.accounts) == 0 {
+		return nil, errNoAccounts
+	}
+	tf := &tx_flood.TxFlood{EvrClient: r.pool, Accounts: r.accounts, Signer: r.signer}
+	addr, err := tf.DeployNumberContract()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"contract": addr.Hex()}, nil
+}
+
+// floodStep floods the network from every account and outputs the block range of the flood
+func floodStep(r *Runner, p *stepParams) (map[string]string, error) {
+	tf := &tx_flood.TxFlood{
+		EvrClient:       r.pool,
+		Accounts:        r.accounts,
+		Signer:          r.signer,
+		NumAcc:          len(r.accounts),
+		NumTxPerAcc:     p.Int("num_tx_per_acc", 1),
+		FloodMode:       tx_flood.FloodMode(p.Int("flood_mode", int(tx_flood.DefaultMode))),
+		Rate:            p.Int("rate", 0),
+		ContractTxValue: p.Wei("sc_tx_value", "0"),
+		ReceiptTimeout:  p.Duration("receipt_timeout", 0),
+		HotSpot:         p.Float64("hot_spot", 0),
+		RandSeed:        p.Int64("rand_seed", 0),
+	}
+	inclusionTimeout := p.Duration("inclusion_timeout", defaultInclusionTimeout)
+	if contract := p.String("contract", ""); contract != "" {
+		if !common.IsHexAddress(contract) {
+			p.fail("contract", contract, "an address")
+		}
+		addr := common.HexToAddress(contract)
+		tf.ContractAddress = &addr
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	if len(r.accounts) == 0 {
+		return nil, errNoAccounts
+	}
+
+	head, err := r.pool.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return nil, err
+	}
+	startErr := tf.Start()
+	// the flood may return before its txs are mined, the range ends with the block mining the last of them
+	last, err := r.waitMined(r.accounts, inclusionTimeout)
+	if err != nil {
+		return nil, err
+	}
+	result := tf.Result()
+	out := map[string]string{
+		"start_block": strconv.FormatUint(head.Number.Uint64()+1, 10),
+		"end_block":   last.String(),
+		"sent":        strconv.FormatUint(result.Sent, 10),
+		"failed":      strconv.FormatUint(result.Failed, 10),
+		"tps":         strconv.FormatFloat(result.TPS(), 'f', 4, 64),
+		"elapsed":     result.Elapsed.String(),
+		"rand_seed":   strconv.FormatInt(result.RandSeed, 10),
+	}
+	return out, startErr
+}
+
+// waitMined waits until every tx sent from accs is mined, checking at every new block until timeout,
+// and returns the block from which they all are
+func (r *Runner) waitMined(accs []*accounts.Account, timeout time.Duration) (*big.Int, error) {
+	ctx := context.Background()
+	// an endpoint may not have seen every tx yet, so the highest pending nonce of the endpoints is waited for
+	targets := make([]uint64, len(accs))
+	for _, e := range r.pool.Endpoints() {
+		for i, acc := range accs {
+			nonce, err := e.Client.PendingNonceAt(ctx, acc.Address)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get nonce of %s", acc.Address.Hex())
+			}
+			if nonce > targets[i] {
+				targets[i] = nonce
+			}
+		}
+	}
+	mined := func(number *big.Int) (bool, error) {
+		for i, acc := range accs {
+			nonce, err := r.client.NonceAt(ctx, acc.Address, number)
+			if err != nil {
+				return false, errors.Wrapf(err, "failed to get nonce of %s", acc.Address.Hex())
+			}
+			if nonce < targets[i] {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+
+	heads := make(chan *types.Header)
+	sub := node.SubscribeNewHeads(r.client, node.DefaultPollInterval, heads)
+	defer sub.Unsubscribe()
+	head, err := r.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		done, err := mined(head.Number)
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			return head.Number, nil
+		}
+		select {
+		case head = <-heads:
+		case <-deadline.C:
+			return nil, fmt.Errorf("txs of the flood are not mined after %s", timeout)
+		}
+	}
+}
+
+// voteStep votes for a candidate of the staking contract from every account
+func voteStep(r *Runner, p *stepParams) (map[string]string, error) {
+	var (
+		stakingSc = p.Address("stakingsc")
+		candidate = p.Address("candidate")
+		amount    = p.Wei("amount", "0")
+		gasLimit  = p.Uint64("gaslimit", sc.DefaultGasLimit)
+		workers   = p.Int("workers", 4)
+	)
+	if p.err != nil {
+		return nil, p.err
+	}
+	if len(r.accounts) == 0 {
+		return nil, errNoAccounts
+	}
+	contract, err := stakingContracts.NewStakingContracts(stakingSc, r.client)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		wg     sync.WaitGroup
+		voters = make(chan *accounts.Account)
+		votes  uint64
+		failed uint64
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for acc := range voters {
+				client := &sc.ContractClient{
+					Contract:  contract,
+					Client:    r.client,
+					StakingSc: stakingSc,
+					Candidate: candidate,
+					GasLimit:  gasLimit,
+					Amount:    amount,
+					TranOps:   node.NewKeyedTransactor(acc.PriKey, r.signer),
+					Logger:    r.Logger,
+					Signer:    r.signer,
+				}
+				tx, err := client.Vote()
+				if err == nil {
+					err = txutil.CheckTransStatus(r.client, tx)
+				}
+				if err != nil {
+					atomic.AddUint64(&failed, 1)
+					r.Logger.Errorw("failed to vote for candidate", "account", acc.Address.Hex(), "error", err)
+					continue
+				}
+				atomic.AddUint64(&votes, 1)
+			}
+		}()
+	}
+	for _, acc := range r.accounts {
+		voters <- acc
+	}
+	close(voters)
+	wg.Wait()
+
+	out := map[string]string{
+		"votes":  strconv.FormatUint(votes, 10),
+		"failed": strconv.FormatUint(failed, 10),
+	}
+	if failed != 0 {
+		return out, fmt.Errorf("%d votes failed", failed)
+	}
+	return out, nil
+}
+
+// metricsStep measures the txs and block time of a block range
+func metricsStep(r *Runner, p *stepParams) (map[string]string, error) {
+	var (
+		start = p.Block("start_block")
+		end   = p.Uint64("end_block", 0)
+	)
+	if p.err != nil {
+		return nil, p.err
+	}
+	if end == 0 {
+		head, err := r.pool.HeaderByNumber(context.Background(), nil)
+		if err != nil {
+			return nil, err
+		}
+		end = head.Number.Uint64()
+	}
+	summary, err := tx_metric.SummaryOf(r.client, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"tps":            strconv.FormatFloat(summary.TPS(), 'f', 4, 64),
+		"block_time":     strconv.FormatFloat(summary.BlockTime(), 'f', 4, 64),
+		"total_tx":       strconv.FormatInt(summary.TotalTx, 10),
+		"total_block":    strconv.FormatInt(summary.TotalBlock, 10),
+		"empty_block":    strconv.FormatInt(summary.EmptyBlocks, 10),
+		"block_time_p95": strconv.FormatFloat(summary.Distribution.BlockTime.P95, 'f', 4, 64),
+		"max_block_time": strconv.FormatFloat(summary.Distribution.BlockTime.Max, 'f', 4, 64),
+	}, nil
+}
+
+// assertStep checks outputs of previous steps against thresholds, params are written as
+//
+//	flood.failed: "== 0"
+//	metrics.tps: ">= 50"
+func assertStep(r *Runner, p *stepParams) (map[string]string, error) {
+	var (
+		refs     []string
+		failures []string
+	)
+	for ref := range p.values {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	for _, ref := range refs {
+		parts := strings.SplitN(ref, ".", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%s does not refer to the output of a step, use step.output", ref)
+		}
+		actual, ok := r.output(parts[0], parts[1])
+		if !ok {
+			return nil, fmt.Errorf("no previous step output %s", ref)
+		}
+		pass, err := check(actual, p.values[ref])
+		if err != nil {
+			return nil, errors.New(ref + ": " + err.Error())
+		}
+		fmt.Printf("%s = %s, expected %s: %t\n", ref, actual, p.values[ref], pass)
+		if !pass {
+			failures = append(failures, fmt.Sprintf("%s = %s, expected %s", ref, actual, p.values[ref]))
+		}
+	}
+	out := map[string]string{
+		"checked": strconv.Itoa(len(refs)),
+		"failed":  strconv.Itoa(len(failures)),
+	}
+	if len(failures) != 0 {
+		return out, fmt.Errorf("assertions failed: %s", strings.Join(failures, "; "))
+	}
+	return out, nil
+}
+
+// operators are the comparisons of assert, longest first so that >= is not read as >
+var operators = []string{">=", "<=", "==", "!=", ">", "<"}
+
+// check compares actual to a condition such as ">= 50", numerically when both sides are numbers
+func check(actual, condition string) (bool, error) {
+	condition = strings.TrimSpace(condition)
+	for _, op := range operators {
+		if !strings.HasPrefix(condition, op) {
+			continue
+		}
+		expected := strings.TrimSpace(strings.TrimPrefix(condition, op))
+		a, aErr := strconv.ParseFloat(actual, 64)
+		e, eErr := strconv.ParseFloat(expected, 64)
+		if aErr != nil || eErr != nil {
+			switch op {
+			case "==":
+				return actual == expected, nil
+			case "!=":
+				return actual != expected, nil
+			}
+			return false, fmt.Errorf("%s %s needs numbers", actual, condition)
+		}
+		switch op {
+		case ">=":
+			return a >= e, nil
+		case "<=":
+			return a <= e, nil
+		case "==":
+			return a == e, nil
+		case "!=":
+			return a != e, nil
+		case ">":
+			return a > e, nil
+		default:
+			return a < e, nil
+		}
+	}
+	return false, fmt.Errorf("condition %q does not start with one of %s", condition, strings.Join(operators, " "))
+}
+
+// sweepStep sends the balance of every account, less the fee, back to an address
+func sweepStep(r *Runner, p *stepParams) (map[string]string, error) {
+	to := p.Address("to")
+	if p.err != nil {
+		return nil, p.err
+	}
+	if len(r.accounts) == 0 {
+		return nil, errNoAccounts
+	}
+	var (
+		gasPrice = big.NewInt(params.GasPriceConfig)
+		fee      = new(big.Int).Mul(big.NewInt(21000), gasPrice)
+		total    = new(big.Int)
+		swept    int
+		ctx      = context.Background()
+	)
+	for _, acc := range r.accounts {
+		balance, err := r.pool.BalanceAt(ctx, acc.Address, nil)
+		if err != nil {
+			return nil, err
+		}
+		if balance.Cmp(fee) <= 0 {
+			continue
+		}
+		nonce, err := r.pool.PendingNonceAt(ctx, acc.Address)
+		if err != nil {
+			return nil, err
+		}
+		value := new(big.Int).Sub(balance, fee)
+		tx, err := types.SignTx(types.NewTransaction(nonce, to, value, 21000, gasPrice, nil), r.signer, acc.PriKey)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.pool.SendTransaction(ctx, tx); err != nil {
+			return nil, fmt.Errorf("failed to sweep %s: %s", acc.Address.Hex(), err)
+		}
+		total.Add(total, value)
+		swept++
+	}
+	return map[string]string{
+		"swept":  strconv.Itoa(swept),
+		"amount": total.String(),
+	}, nil
+}
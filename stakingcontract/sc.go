@@ -62,17 +62,20 @@ var (
 
 // NewStakingFlag returns flags for Staking contract client (register/ resign)
 func NewStakingFlag() []cli.Flag {
-	return []cli.Flag{stakingScFlag, senderPkFlag, candidateFlag, gasLimitFlag}
+	flags := []cli.Flag{stakingScFlag, senderPkFlag, candidateFlag, gasLimitFlag}
+	return append(flags, node.NewSignerFlags()...)
 }
 
 // NewStakingVoteOrUnVoteFlag returns flags for Staking contract client (vote/ unvote method)
 func NewStakingVoteOrUnVoteFlag() []cli.Flag {
-	return []cli.Flag{stakingScFlag, senderPkFlag, candidateFlag, gasLimitFlag, amountFlag}
+	flags := []cli.Flag{stakingScFlag, senderPkFlag, candidateFlag, gasLimitFlag, amountFlag}
+	return append(flags, node.NewSignerFlags()...)
 }
 
 // NewStressTestFlag returns flags for Staking contract client
 func NewStressTestFlag() []cli.Flag {
-	return []cli.Flag{stakingScFlag, senderPkFlag, candidateFlag, gasLimitFlag, numVoterFlag, numWorkerFlag, amountFlag}
+	flags := []cli.Flag{stakingScFlag, senderPkFlag, candidateFlag, gasLimitFlag, numVoterFlag, numWorkerFlag, amountFlag}
+	return append(flags, node.NewSignerFlags()...)
 }
 
 // ContractClient returns a struct
@@ -88,6 +91,8 @@ type ContractClient struct {
 	NumWorker int
 	TranOps   *bind.TransactOpts
 	Logger    *zap.SugaredLogger
+	// Signer signs every tx sent to the contract
+	Signer types.Signer
 }
 
 // NewContractClientFromFlags returns new instance of contract client.
@@ -117,6 +122,10 @@ func NewContractClientFromFlags(ctx *cli.Context, logger *zap.SugaredLogger) (*C
 	if err != nil {
 		return nil, err
 	}
+	signer, err := node.SignerFromFlags(ctx, client)
+	if err != nil {
+		return nil, err
+	}
 	stakeSCAddr := common.HexToAddress(stakingSc)
 	contract, err := stakingContracts.NewStakingContracts(stakeSCAddr, client)
 	if err != nil {
@@ -131,10 +140,11 @@ func NewContractClientFromFlags(ctx *cli.Context, logger *zap.SugaredLogger) (*C
 		Candidate: common.HexToAddress(candidate),
 		GasLimit:  gasLimit,
 		Amount:    amount,
-		TranOps:   bind.NewKeyedTransactor(senderPk),
+		TranOps:   node.NewKeyedTransactor(senderPk, signer),
 		NumVoter:  numVoter,
 		NumWorker: numWorker,
 		Logger:    logger,
+		Signer:    signer,
 	}
 	return contractClient, nil
 }
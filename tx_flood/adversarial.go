@@ -0,0 +1,248 @@
+package tx_flood
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+
+	"github.com/evrynet-official/evrynet-tools/accounts"
+	"github.com/evrynet-official/evrynet-tools/lib/node"
+)
+
+// Category is a kind of malformed tx sent by an Adversary
+type Category string
+
+const (
+	// BadSignature txs carry a signature that does not recover to any sender
+	BadSignature Category = "bad-signature"
+	// NonceGap txs skip nonces after the pending nonce, the node is expected to queue them.
+	// The gap is filled afterwards so that the txs do not stay queued.
+	NonceGap Category = "nonce-gap"
+	// Underpriced txs pay less than the gas price of the network
+	Underpriced Category = "underpriced"
+	// GasAboveLimit txs ask for more gas than the block gas limit
+	GasAboveLimit Category = "gas-above-limit"
+	// OversizedData txs carry more calldata than the txpool accepts
+	OversizedData Category = "oversized-data"
+	// WrongChainID txs are signed for another chain
+	WrongChainID Category = "wrong-chain-id"
+	// Duplicate txs were already submitted, only the second submission is recorded
+	Duplicate Category = "duplicate"
+)
+
+// Categories are all the kinds of malformed txs, in the order they are sent
+var Categories = []Category{BadSignature, NonceGap, Underpriced, GasAboveLimit, OversizedData, WrongChainID, Duplicate}
+
+const (
+	// acceptedOutcome is recorded when the node accepts a tx
+	acceptedOutcome = "accepted"
+	nonceGap        = 3
+	oversizedData   = 128 * 1024
+)
+
+// Adversary sends malformed txs to check that the node rejects each of them cleanly,
+// while an honest flood checks that the node keeps serving well-formed txs
+type Adversary struct {
+	EvrClient *node.Pool
+	// Accounts send the malformed txs, they must not be used by Honest
+	Accounts []*accounts.Account
+	// Rounds is the number of times every account sends every category
+	Rounds int
+	// Honest runs alongside the malformed txs when set
+	Honest *TxFlood
+	// Signer signs the txs that are not malformed by their signature,
+	// an EIP-155 signer of the chain id of the node is used when it is nil
+	Signer types.Signer
+
+	mu       *sync.Mutex
+	outcomes map[Category]map[string]int
+	chainID  *big.Int
+	gasLimit uint64
+}
+
+// AdversaryReport is the outcome of every category and of the honest flood
+type AdversaryReport struct {
+	// Outcomes counts the error returned by the node for each category, or accepted
+	Outcomes  map[Category]map[string]int
+	Honest    *Result
+	HonestErr error
+}
+
+// Start sends Rounds of every category from every account and returns an error when the node
+// accepted a malformed tx or the honest flood failed. Nonce gaps are expected to be accepted.
+func (a *Adversary) Start() (*AdversaryReport, error) {
+	var (
+		honest sync.WaitGroup
+		report = &AdversaryReport{}
+	)
+	a.mu = &sync.Mutex{}
+	a.outcomes = make(map[Category]map[string]int)
+	if err := a.prepare(); err != nil {
+		return nil, err
+	}
+
+	if a.Honest != nil {
+		honest.Add(1)
+		go func() {
+			defer honest.Done()
+			report.HonestErr = a.Honest.Start()
+			result := a.Honest.Result()
+			report.Honest = &result
+		}()
+	}
+	for round := 0; round < a.Rounds; round++ {
+		var wg sync.WaitGroup
+		for _, acc := range a.Accounts {
+			wg.Add(1)
+			go func(acc *accounts.Account) {
+				defer wg.Done()
+				for _, category := range Categories {
+					a.record(category, a.send(category, acc))
+				}
+			}(acc)
+		}
+		wg.Wait()
+	}
+	honest.Wait()
+	report.Outcomes = a.outcomes
+
+	if report.HonestErr != nil {
+		return report, errors.Wrap(report.HonestErr, "honest flood failed")
+	}
+	for _, category := range Categories {
+		if category != NonceGap && report.Outcomes[category][acceptedOutcome] != 0 {
+			return report, fmt.Errorf("node accepted %d %s txs", report.Outcomes[category][acceptedOutcome], category)
+		}
+	}
+	return report, nil
+}
+
+// prepare reads the chain id and block gas limit the malformed txs are built from
+func (a *Adversary) prepare() error {
+	var err error
+	if a.chainID, err = a.EvrClient.ChainID(context.Background()); err != nil {
+		return errors.Wrap(err, "failed to get chain id")
+	}
+	header, err := a.EvrClient.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to get latest block")
+	}
+	a.gasLimit = header.GasLimit
+	if a.Signer == nil {
+		a.Signer = types.NewEIP155Signer(a.chainID)
+	}
+	return nil
+}
+
+func (a *Adversary) record(category Category, err error) {
+	outcome := acceptedOutcome
+	if err != nil {
+		outcome = err.Error()
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.outcomes[category] == nil {
+		a.outcomes[category] = make(map[string]int)
+	}
+	a.outcomes[category][outcome]++
+}
+
+// send sends one tx of category from acc and returns the response of the node
+func (a *Adversary) send(category Category, acc *accounts.Account) error {
+	nonce, err := a.EvrClient.PendingNonceAt(context.Background(), acc.Address)
+	if err != nil {
+		return errors.Wrap(err, "failed to get nonce")
+	}
+	var (
+		ctx    = context.Background()
+		signer = a.Signer
+		tx     = types.NewTransaction(nonce, acc.Address, common.Big1, 21000, gasPrice, nil)
+	)
+	switch category {
+	case BadSignature:
+		// zero r and s are out of range for every signer
+		signed, err := tx.WithSignature(signer, make([]byte, 65))
+		if err != nil {
+			return err
+		}
+		return a.EvrClient.SendTransaction(ctx, signed)
+	case NonceGap:
+		tx = types.NewTransaction(nonce+nonceGap, acc.Address, common.Big1, 21000, gasPrice, nil)
+		signed, err := types.SignTx(tx, signer, acc.PriKey)
+		if err != nil {
+			return err
+		}
+		if err := a.EvrClient.SendTransaction(ctx, signed); err != nil {
+			return err
+		}
+		return a.fillGap(acc, nonce, nonce+nonceGap)
+	case Underpriced:
+		tx = types.NewTransaction(nonce, acc.Address, common.Big1, 21000, new(big.Int).Sub(gasPrice, common.Big1), nil)
+	case GasAboveLimit:
+		tx = types.NewTransaction(nonce, acc.Address, common.Big1, a.gasLimit+1, gasPrice, nil)
+	case OversizedData:
+		data := make([]byte, oversizedData)
+		tx = types.NewTransaction(nonce, acc.Address, common.Big1, a.gasLimit, gasPrice, data)
+	case WrongChainID:
+		signer = types.NewEIP155Signer(new(big.Int).Add(a.chainID, common.Big1))
+	case Duplicate:
+		signed, err := types.SignTx(tx, signer, acc.PriKey)
+		if err != nil {
+			return err
+		}
+		if err := a.EvrClient.SendTransaction(ctx, signed); err != nil {
+			return errors.Wrap(err, "first submission failed")
+		}
+		return a.EvrClient.SendTransaction(ctx, signed)
+	}
+	signed, err := types.SignTx(tx, signer, acc.PriKey)
+	if err != nil {
+		return err
+	}
+	return a.EvrClient.SendTransaction(ctx, signed)
+}
+
+// fillGap sends txs from acc with the nonces from first to before last, so that the tx of nonce last is no longer
+// queued and does not hold back the next txs of acc
+func (a *Adversary) fillGap(acc *accounts.Account, first, last uint64) error {
+	for nonce := first; nonce < last; nonce++ {
+		// a zero value keeps the fillers apart from the valid txs of other categories
+		signed, err := types.SignTx(types.NewTransaction(nonce, acc.Address, common.Big0, 21000, gasPrice, nil), a.Signer, acc.PriKey)
+		if err != nil {
+			return err
+		}
+		if err := a.EvrClient.SendTransaction(context.Background(), signed); err != nil {
+			return errors.Wrap(err, "failed to fill nonce gap")
+		}
+	}
+	return nil
+}
+
+// Print prints the outcome of every category and of the honest flood on console view
+func (r *AdversaryReport) Print() {
+	fmt.Println("-----------Adversarial Stats----------------")
+	for _, category := range Categories {
+		var outcomes []string
+		for outcome := range r.Outcomes[category] {
+			outcomes = append(outcomes, outcome)
+		}
+		sort.Strings(outcomes)
+		for _, outcome := range outcomes {
+			fmt.Printf("%s: %s x%d\n", category, outcome, r.Outcomes[category][outcome])
+		}
+	}
+	if r.Honest != nil {
+		fmt.Println("-----------Honest Stats----------------")
+		fmt.Printf("Sent: %d, failed: %d, elapsed: %s, TPS: %.2f\n", r.Honest.Sent, r.Honest.Failed, r.Honest.Elapsed, r.Honest.TPS())
+		if r.HonestErr != nil {
+			fmt.Println("Error:", r.HonestErr)
+		}
+	}
+}
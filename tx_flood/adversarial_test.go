@@ -0,0 +1,72 @@
+package tx_flood
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+
+	"github.com/evrynet-official/evrynet-tools/accounts"
+	"github.com/evrynet-official/evrynet-tools/lib/node"
+)
+
+func TestAdversary_Start(t *testing.T) {
+	known := make(map[string]bool)
+	stub := &txStub{validate: func(tx *types.Transaction) error {
+		// the checks of the node txpool, in the same order
+		switch {
+		case tx.Size() > 32*1024:
+			return errors.New("oversized data")
+		case tx.Gas() > 8000000:
+			return errors.New("exceeds block gas limit")
+		case tx.Protected() && tx.ChainId().Int64() != 1:
+			return errors.New("invalid sender")
+		case tx.GasPrice().Cmp(gasPrice) != 0:
+			return errors.New("Tx gasPrice is different from gasPrice of the network")
+		case known[tx.Hash().Hex()]:
+			return errors.New("known transaction")
+		}
+		if _, err := node.TxSender(tx); err != nil {
+			return errors.New("invalid sender")
+		}
+		known[tx.Hash().Hex()] = true
+		return nil
+	}}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	pool, err := node.DialPool([]string{server.URL}, node.RoundRobin)
+	assert.NoError(t, err)
+	accs, err := accounts.GenerateAccounts(2, "adversary")
+	assert.NoError(t, err)
+
+	adversary := &Adversary{EvrClient: pool, Accounts: accs, Rounds: 1}
+	report, err := adversary.Start()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"invalid sender": 2}, report.Outcomes[BadSignature])
+	assert.Equal(t, map[string]int{acceptedOutcome: 2}, report.Outcomes[NonceGap])
+	assert.Equal(t, map[string]int{"Tx gasPrice is different from gasPrice of the network": 2}, report.Outcomes[Underpriced])
+	assert.Equal(t, map[string]int{"exceeds block gas limit": 2}, report.Outcomes[GasAboveLimit])
+	assert.Equal(t, map[string]int{"oversized data": 2}, report.Outcomes[OversizedData])
+	assert.Equal(t, map[string]int{"invalid sender": 2}, report.Outcomes[WrongChainID])
+	assert.Equal(t, map[string]int{"known transaction": 2}, report.Outcomes[Duplicate])
+	// the gap of every account is filled
+	filled := make(map[string][]uint64)
+	for _, tx := range stub.txs {
+		from, err := node.TxSender(tx)
+		assert.NoError(t, err)
+		if tx.Nonce() != 0 || tx.Value().Sign() == 0 {
+			filled[from.Hex()] = append(filled[from.Hex()], tx.Nonce())
+		}
+	}
+	for _, acc := range accs {
+		assert.Equal(t, []uint64{nonceGap, 0, 1, 2}, filled[acc.Address.Hex()])
+	}
+
+	// a malformed tx accepted by the node fails the run
+	stub.validate = nil
+	_, err = adversary.Start()
+	assert.Error(t, err)
+}
@@ -0,0 +1,184 @@
+package tx_flood
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/evrynet-official/evrynet-tools/lib/node"
+)
+
+// Backpressure adjusts the send rate of a flood from the txpool status of the node and the failed txs:
+// the rate is halved when the txpool holds more than High txs or too many txs fail, and raised step by step
+// up to MaxRate while the txpool holds fewer than Low txs
+type Backpressure struct {
+	EvrClient *node.Pool
+	// MaxRate is the rate the flood starts at and never exceeds
+	MaxRate int
+	// High is the number of pending and queued txs above which the rate is halved
+	High uint64
+	// Low is the number of pending and queued txs below which the rate is raised
+	Low uint64
+	// MaxErrorRatio is the ratio of failed txs in an interval above which the rate is halved
+	MaxErrorRatio float64
+	// Interval is the time between two polls of the txpool status
+	Interval time.Duration
+
+	mu         *sync.Mutex
+	rate       int
+	backoffs   int
+	raises     int
+	maxPool    uint64
+	backedOff  bool
+	sustained  time.Duration
+	sustainedN float64
+}
+
+// Print prints the adjustments of the rate and the sustainable rate on console view
+func (b *Backpressure) Print() {
+	if b.mu == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	fmt.Println("-----------Backpressure Stats----------------")
+	fmt.Println("Max rate:", b.MaxRate)
+	fmt.Println("Final rate:", b.rate)
+	fmt.Println("Back-offs:", b.backoffs)
+	fmt.Println("Raises:", b.raises)
+	fmt.Println("Largest txpool:", b.maxPool)
+	fmt.Printf("=> Sustainable rate: %.2f tx/s\n", b.sustainableRate())
+}
+
+// SustainableRate returns the average rate of the intervals the txpool kept up with since the first back-off,
+// or MaxRate when the rate was never halved
+func (b *Backpressure) SustainableRate() float64 {
+	if b.mu == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sustainableRate()
+}
+
+func (b *Backpressure) sustainableRate() float64 {
+	if !b.backedOff {
+		return float64(b.MaxRate)
+	}
+	if b.sustained == 0 {
+		return float64(b.rate)
+	}
+	return b.sustainedN / b.sustained.Seconds()
+}
+
+func (b *Backpressure) currentRate() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.rate
+}
+
+// reset starts the controller at MaxRate
+func (b *Backpressure) reset() {
+	b.mu = &sync.Mutex{}
+	b.rate = b.MaxRate
+	b.backoffs, b.raises, b.maxPool = 0, 0, 0
+	b.backedOff, b.sustained, b.sustainedN = false, 0, 0
+}
+
+// adjust takes the number of txs in the txpool and of sent and failed txs during the last interval,
+// and returns the rate for the next interval
+func (b *Backpressure) adjust(pool, sent, failed uint64, interval time.Duration) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if pool > b.maxPool {
+		b.maxPool = pool
+	}
+	overloaded := pool > b.High
+	if total := sent + failed; total != 0 && float64(failed)/float64(total) > b.MaxErrorRatio {
+		overloaded = true
+	}
+	switch {
+	case overloaded:
+		b.backedOff = true
+		b.backoffs++
+		b.rate /= 2
+		if b.rate < 1 {
+			b.rate = 1
+		}
+		return b.rate
+	case b.backedOff:
+		// the txpool kept up with the rate of this interval
+		b.sustained += interval
+		b.sustainedN += float64(b.rate) * interval.Seconds()
+	}
+	if pool < b.Low && b.rate < b.MaxRate {
+		step := b.MaxRate / 20
+		if step < 1 {
+			step = 1
+		}
+		b.rate += step
+		if b.rate > b.MaxRate {
+			b.rate = b.MaxRate
+		}
+		b.raises++
+	}
+	return b.rate
+}
+
+// start paces tick at the rate of the controller and adjusts the rate every interval until done is closed
+func (b *Backpressure) start(tf *TxFlood, tick chan<- time.Time, done <-chan struct{}) {
+	b.reset()
+	go func() {
+		next := time.Now()
+		for {
+			// schedule each tick from the previous one, so that delays do not add up,
+			// but do not catch up on more than a second after the rate was raised
+			if now := time.Now(); next.Before(now.Add(-time.Second)) {
+				next = now
+			}
+			next = next.Add(time.Second / time.Duration(b.currentRate()))
+			select {
+			case <-done:
+				return
+			case <-time.After(time.Until(next)):
+			}
+			select {
+			case <-done:
+				return
+			case tick <- next:
+			}
+		}
+	}()
+	go func() {
+		var (
+			ticker     = time.NewTicker(b.Interval)
+			lastSent   uint64
+			lastFailed uint64
+		)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			status, err := b.EvrClient.TxpoolStatus(context.Background(), nil)
+			if err != nil {
+				fmt.Printf("failed to get txpool status, error %s\n", err)
+				continue
+			}
+			var (
+				sent   = atomic.LoadUint64(&tf.sent)
+				failed = atomic.LoadUint64(&tf.failed)
+				pool   = uint64(status.Pending) + uint64(status.Queued)
+				prev   = b.currentRate()
+			)
+			if rate := b.adjust(pool, sent-lastSent, failed-lastFailed, b.Interval); rate != prev {
+				fmt.Printf("Txpool holds %d txs, rate %d => %d tx/s\n", pool, prev, rate)
+			}
+			lastSent, lastFailed = sent, failed
+		}
+	}()
+}
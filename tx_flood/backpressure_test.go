@@ -0,0 +1,78 @@
+package tx_flood
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+
+	"github.com/evrynet-official/evrynet-tools/accounts"
+	"github.com/evrynet-official/evrynet-tools/lib/node"
+)
+
+func TestBackpressure_adjust(t *testing.T) {
+	b := &Backpressure{MaxRate: 100, High: 1000, Low: 100, MaxErrorRatio: 0.1}
+	b.reset()
+
+	// a draining txpool keeps the maximum rate
+	assert.Equal(t, 100, b.adjust(10, 100, 0, time.Second))
+	assert.Equal(t, float64(100), b.SustainableRate())
+	// a full txpool or failing txs halve the rate
+	assert.Equal(t, 50, b.adjust(2000, 100, 0, time.Second))
+	assert.Equal(t, 25, b.adjust(500, 30, 20, time.Second))
+	// the rate holds between the thresholds and is raised below Low
+	assert.Equal(t, 25, b.adjust(500, 25, 0, time.Second))
+	assert.Equal(t, 30, b.adjust(50, 25, 0, time.Second))
+	// the intervals at 25 tx/s were kept up with
+	assert.Equal(t, float64(25), b.SustainableRate())
+	assert.Equal(t, 2, b.backoffs)
+	assert.Equal(t, 1, b.raises)
+	assert.Equal(t, uint64(2000), b.maxPool)
+}
+
+func TestTxFlood_Backpressure(t *testing.T) {
+	var (
+		stub  = &txStub{nonce: 0}
+		mined int
+	)
+	stub.methods = map[string]func() interface{}{
+		// the txpool holds the txs sent since the last poll
+		"txpool_status": func() interface{} {
+			pending := len(stub.txs) - mined
+			mined = len(stub.txs)
+			return node.TxpoolStatus{Pending: hexutil.Uint(pending)}
+		},
+	}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	pool, err := node.DialPool([]string{server.URL}, node.RoundRobin)
+	require.NoError(t, err)
+
+	accs, err := accounts.GenerateAccounts(2, "backpressure")
+	require.NoError(t, err)
+	tf := &TxFlood{
+		NumAcc:      2,
+		NumTxPerAcc: 50,
+		FloodMode:   NormalTxMode,
+		EvrClient:   pool,
+		Accounts:    accs,
+		Backpressure: &Backpressure{
+			EvrClient:     pool,
+			MaxRate:       1000,
+			High:          10,
+			Low:           5,
+			MaxErrorRatio: 0.1,
+			Interval:      50 * time.Millisecond,
+		},
+	}
+	require.NoError(t, tf.Start())
+	assert.Equal(t, uint64(100), tf.Result().Sent)
+	// the txpool grew past High, so the rate was lowered
+	assert.NotZero(t, tf.Backpressure.backoffs)
+	assert.True(t, tf.Backpressure.currentRate() < 1000)
+	assert.True(t, tf.Result().SustainableRate < 1000)
+}
@@ -0,0 +1,149 @@
+package tx_flood
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+
+	"github.com/evrynet-official/evrynet-tools/accounts"
+	"github.com/evrynet-official/evrynet-tools/lib/metrics"
+)
+
+// hotSlot is the storage slot written by the hot-spot txs of StorageMode
+var hotSlot = common.Hash{}
+
+// trackedTx is a tx whose inclusion is reported after the run
+type trackedTx struct {
+	hash   common.Hash
+	sentAt time.Time
+	hot    bool
+}
+
+// InclusionStats is how long the txs of a group took to be mined, measured to the timestamp of their block
+type InclusionStats struct {
+	Sent       int           `json:"sent"`
+	Mined      int           `json:"mined"`
+	Reverted   int           `json:"reverted"`
+	Missing    int           `json:"missing"`
+	AvgLatency time.Duration `json:"avg_latency"`
+	MaxLatency time.Duration `json:"max_latency"`
+}
+
+func (s *InclusionStats) print(group string) {
+	fmt.Printf("%s txs: sent %d, mined %d, reverted %d, without receipt %d, avg latency %s, max latency %s\n",
+		group, s.Sent, s.Mined, s.Reverted, s.Missing, s.AvgLatency, s.MaxLatency)
+}
+
+func (tf *TxFlood) sendStorageTx(acc *accounts.Account, nonce *big.Int, contractAddr *common.Address, rng *rand.Rand) error {
+	tx, hot, err := tf.newStorageTx(acc, nonce.Uint64(), *contractAddr, rng)
+	if err != nil {
+		return err
+	}
+
+	err = tf.EvrClient.SendTransaction(context.Background(), tx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to send Tx to SC %s from %s nonce %s", contractAddr.Hex(), acc.Address.Hex(), nonce.String())
+	}
+	nonce = nonce.Add(nonce, common.Big1)
+	atomic.AddUint64(&tf.sent, 1)
+	metrics.TxsSent.Inc()
+	fmt.Printf("Sent store from %s => SC %s, hot spot: %t\n", acc.Address.Hex(), contractAddr.Hex(), hot)
+	tf.track(tx, hot)
+	return nil
+}
+
+// newStorageTx returns a signed tx writing a random value to the slot of acc in the contract,
+// or to the hot-spot slot when the tx is picked for the hot spot
+func (tf *TxFlood) newStorageTx(acc *accounts.Account, nonce uint64, contractAddr common.Address, rng *rand.Rand) (*types.Transaction, bool, error) {
+	var (
+		estGas uint64 = 60000
+		hot           = rng.Float64() < tf.HotSpot
+		slot          = common.BytesToHash(acc.Address.Bytes())
+		value         = big.NewInt(rng.Int63n(1000) + 1)
+	)
+	if hot {
+		slot = hotSlot
+	}
+	tx := types.NewTransaction(nonce, contractAddr, common.Big0, estGas, gasPrice, slotData(slot, value))
+	tx, err := types.SignTx(tx, tf.Signer, acc.PriKey)
+	return tx, hot, err
+}
+
+// track records a sent tx to report its inclusion after the run
+func (tf *TxFlood) track(tx *types.Transaction, hot bool) {
+	if !tf.TrackInclusion || tf.Continuous {
+		return
+	}
+	tf.mu.Lock()
+	defer tf.mu.Unlock()
+	tf.tracked = append(tf.tracked, trackedTx{hash: tx.Hash(), sentAt: time.Now(), hot: hot})
+}
+
+// reportInclusion waits for the receipts of the tracked txs and prints how long hot-spot and other txs took to be mined
+func (tf *TxFlood) reportInclusion() {
+	if len(tf.tracked) == 0 {
+		return
+	}
+	var (
+		hot, other = &InclusionStats{}, &InclusionStats{}
+		blockTimes = make(map[uint64]time.Time)
+		timeout    = tf.ReceiptTimeout
+	)
+	if timeout == 0 {
+		timeout = defaultReceiptTimeout
+	}
+	fmt.Printf("--- Waiting for %d transactions to be mined ...\n", len(tf.tracked))
+	deadline := time.Now().Add(timeout)
+	for _, sent := range tf.tracked {
+		stats := other
+		if sent.hot {
+			stats = hot
+		}
+		stats.Sent++
+		receipt, err := tf.waitForReceipt(sent.hash, deadline)
+		if err != nil {
+			stats.Missing++
+			continue
+		}
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			stats.Reverted++
+			continue
+		}
+		number := receipt.BlockNumber.Uint64()
+		minedAt, ok := blockTimes[number]
+		if !ok {
+			header, err := tf.EvrClient.HeaderByNumber(context.Background(), receipt.BlockNumber)
+			if err != nil {
+				fmt.Printf("failed to get block %d, error %s\n", number, err)
+				stats.Missing++
+				continue
+			}
+			minedAt = time.Unix(int64(header.Time), 0)
+			blockTimes[number] = minedAt
+		}
+		// block timestamps are in seconds, a tx mined in the second it was sent took no time
+		latency := minedAt.Sub(sent.sentAt)
+		if latency < 0 {
+			latency = 0
+		}
+		stats.Mined++
+		stats.AvgLatency += (latency - stats.AvgLatency) / time.Duration(stats.Mined)
+		if latency > stats.MaxLatency {
+			stats.MaxLatency = latency
+		}
+	}
+
+	tf.hot, tf.other = hot, other
+	fmt.Println("-----------Contention Stats----------------")
+	fmt.Printf("Hot spot: %.2f\n", tf.HotSpot)
+	hot.print("Hot-spot")
+	other.print("Other")
+}
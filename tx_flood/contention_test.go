@@ -0,0 +1,95 @@
+package tx_flood
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/accounts/abi/bind/backends"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/core"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+
+	"github.com/evrynet-official/evrynet-tools/accounts"
+)
+
+func TestSlotContract(t *testing.T) {
+	accs, err := accounts.GenerateAccounts(2, "contention")
+	require.NoError(t, err)
+	var (
+		signer = types.HomesteadSigner{}
+		alloc  = core.GenesisAlloc{}
+		ctx    = context.Background()
+	)
+	for _, acc := range accs {
+		alloc[acc.Address] = core.GenesisAccount{Balance: new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)}
+	}
+	sim := backends.NewSimulatedBackend(alloc, 8000000)
+
+	bin, err := hexutil.Decode(slotContractBin)
+	require.NoError(t, err)
+	deploy, err := types.SignTx(types.NewContractCreation(0, common.Big0, 100000, gasPrice, bin), signer, accs[0].PriKey)
+	require.NoError(t, err)
+	require.NoError(t, sim.SendTransaction(ctx, deploy))
+	sim.Commit()
+	receipt, err := sim.TransactionReceipt(ctx, deploy.Hash())
+	require.NoError(t, err)
+	require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+
+	// every account writes its own slot and the hot-spot slot
+	tf := &TxFlood{Accounts: accs, Signer: signer, HotSpot: 0.5}
+	rng := rand.New(rand.NewSource(1))
+	nonces := map[common.Address]uint64{accs[0].Address: 1}
+	written := make(map[common.Hash]bool)
+	for i := 0; i < 8; i++ {
+		acc := accs[i%2]
+		tx, hot, err := tf.newStorageTx(acc, nonces[acc.Address], receipt.ContractAddress, rng)
+		require.NoError(t, err)
+		nonces[acc.Address]++
+		require.NoError(t, sim.SendTransaction(ctx, tx))
+		sim.Commit()
+		txReceipt, err := sim.TransactionReceipt(ctx, tx.Hash())
+		require.NoError(t, err)
+		assert.Equal(t, types.ReceiptStatusSuccessful, txReceipt.Status)
+
+		slot := common.BytesToHash(acc.Address.Bytes())
+		if hot {
+			slot = hotSlot
+		}
+		written[slot] = true
+		stored, err := sim.StorageAt(ctx, receipt.ContractAddress, slot, nil)
+		require.NoError(t, err)
+		assert.Equal(t, common.BytesToHash(tx.Data()[32:]), common.BytesToHash(stored))
+	}
+	assert.True(t, written[hotSlot])
+	assert.Len(t, written, 3)
+}
+
+func TestTxFlood_newNormalTx_hotSpot(t *testing.T) {
+	accs, err := accounts.GenerateAccounts(4, "contention")
+	require.NoError(t, err)
+	receiver := common.HexToAddress("0x1")
+
+	for _, tt := range []struct {
+		hotSpot float64
+		wantHot int
+	}{{0, 0}, {1, 100}} {
+		tf := &TxFlood{Accounts: accs, Signer: types.HomesteadSigner{}, HotSpot: tt.hotSpot, HotReceiver: &receiver}
+		rng := rand.New(rand.NewSource(1))
+		var hotTxs int
+		for i := 0; i < 100; i++ {
+			tx, hot, err := tf.newNormalTx(accs[i%4], uint64(i), rng)
+			require.NoError(t, err)
+			assert.Equal(t, hot, *tx.To() == receiver)
+			if hot {
+				hotTxs++
+			}
+		}
+		assert.Equal(t, tt.wantHot, hotTxs)
+	}
+}
@@ -0,0 +1,77 @@
+package tx_flood
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/Evrynetlabs/evrynet-node"
+	"github.com/Evrynetlabs/evrynet-node/accounts/abi"
+	"github.com/Evrynetlabs/evrynet-node/common"
+)
+
+const (
+	// numberContractBin is the bytecode of a simple storage contract:
+	//
+	//	contract Number {
+	//		uint256 public number;
+	//		function setNumber(uint256 n) public payable { number = n; }
+	//		function getNumber() public view returns (uint256) { return number; }
+	//	}
+	numberContractBin = "0x608060405260d0806100126000396000f30060806040526004361060525763ffffffff7c01000000000000000000000000000000000000000000000000000000006000350416633fb5c1cb811460545780638381f58a14605d578063f2c9ecd8146081575b005b60526004356093565b348015606857600080fd5b50606f6098565b60408051918252519081900360200190f35b348015608c57600080fd5b50606f609e565b600055565b60005481565b600054905600a165627a7a723058209573e4f95d10c1e123e905d720655593ca5220830db660f0641f3175c1cdb86e0029"
+
+	// slotContractBin is the bytecode of a contract storing the second word of the calldata
+	// at the slot given by the first word:
+	//
+	//	PUSH1 0x20 CALLDATALOAD PUSH1 0x00 CALLDATALOAD SSTORE STOP
+	//
+	// behind init code returning those 8 bytes.
+	slotContractBin = "0x600880600b6000396000f36020356000355500"
+
+	// numberContractABI is the ABI of the contract deployed from numberContractBin.
+	numberContractABI = `[
+	{"constant":false,"inputs":[{"name":"n","type":"uint256"}],"name":"setNumber","outputs":[],"payable":true,"stateMutability":"payable","type":"function"},
+	{"constant":true,"inputs":[],"name":"number","outputs":[{"name":"","type":"uint256"}],"payable":false,"stateMutability":"view","type":"function"},
+	{"constant":true,"inputs":[],"name":"getNumber","outputs":[{"name":"","type":"uint256"}],"payable":false,"stateMutability":"view","type":"function"}
+]`
+)
+
+// numberContract builds calldata for and reads state from the contract deployed by SmartContractMode.
+type numberContract struct {
+	abi abi.ABI
+}
+
+func newNumberContract() (*numberContract, error) {
+	parsed, err := abi.JSON(strings.NewReader(numberContractABI))
+	if err != nil {
+		return nil, err
+	}
+	return &numberContract{abi: parsed}, nil
+}
+
+// setNumberData returns the calldata to call setNumber(n).
+func (c *numberContract) setNumberData(n *big.Int) ([]byte, error) {
+	return c.abi.Pack("setNumber", n)
+}
+
+// getNumber reads the number stored in the contract at the latest block.
+func (c *numberContract) getNumber(caller evrynet.ContractCaller, contractAddr common.Address) (*big.Int, error) {
+	data, err := c.abi.Pack("getNumber")
+	if err != nil {
+		return nil, err
+	}
+	output, err := caller.CallContract(context.Background(), evrynet.CallMsg{To: &contractAddr, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	var number *big.Int
+	if err := c.abi.Unpack(&number, "getNumber", output); err != nil {
+		return nil, err
+	}
+	return number, nil
+}
+
+// slotData returns the calldata storing value at slot of the contract deployed from slotContractBin.
+func slotData(slot common.Hash, value *big.Int) []byte {
+	return append(slot.Bytes(), common.BigToHash(value).Bytes()...)
+}
@@ -0,0 +1,410 @@
+package tx_flood
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+
+	"github.com/evrynet-official/evrynet-tools/accounts"
+	"github.com/evrynet-official/evrynet-tools/lib/node"
+)
+
+// AgentRunPath is the HTTP path an agent accepts jobs on
+const AgentRunPath = "/run"
+
+// agentReadTimeout is the time a coordinator has to send a job to an agent
+const agentReadTimeout = 30 * time.Second
+
+// Job is the share of a distributed flood assigned to one agent
+type Job struct {
+	AccountOffset   int           `json:"account_offset"`
+	NumAcc          int           `json:"num_acc"`
+	NumTxPerAcc     int           `json:"num_tx_per_acc"`
+	Seed            string        `json:"seed"`
+	FloodMode       FloodMode     `json:"flood_mode"`
+	Rate            int           `json:"rate"`
+	ContractTxValue string        `json:"sc_tx_value"`
+	ReceiptTimeout  time.Duration `json:"receipt_timeout"`
+	RPCEndpoints    []string      `json:"rpc_endpoints"`
+	RPCPolicy       node.Policy   `json:"rpc_policy"`
+	// ChainID is the chain id txs are signed for, 0 means the chain id of the node
+	ChainID      uint64 `json:"chain_id"`
+	LegacySigner bool   `json:"legacy_signer"`
+	// RandSeed is the seed of the whole run, every agent derives the streams of its accounts from it
+	RandSeed int64 `json:"rand_seed"`
+	// HotSpot, HotReceiver and TrackInclusion are those of TxFlood,
+	// the coordinator sets the hot receiver so that all agents send to the same account
+	HotSpot        float64         `json:"hot_spot"`
+	HotReceiver    *common.Address `json:"hot_receiver,omitempty"`
+	TrackInclusion bool            `json:"track_inclusion"`
+	StartAt        time.Time       `json:"start_at"`
+	// ContractAddress is the contract every agent calls instead of deploying its own
+	ContractAddress *common.Address `json:"contract_address,omitempty"`
+}
+
+// AgentResult is the response of an agent after running a job
+type AgentResult struct {
+	Agent  string `json:"agent"`
+	Job    Job    `json:"job"`
+	Result Result `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// NewTxFloodFromJob returns a TxFlood that sends the txs of a job
+func NewTxFloodFromJob(job Job) (*TxFlood, error) {
+	tf := &TxFlood{
+		NumAcc:          job.NumAcc,
+		NumTxPerAcc:     job.NumTxPerAcc,
+		Seed:            job.Seed,
+		FloodMode:       job.FloodMode,
+		ReceiptTimeout:  job.ReceiptTimeout,
+		Rate:            job.Rate,
+		RandSeed:        job.RandSeed,
+		HotSpot:         job.HotSpot,
+		HotReceiver:     job.HotReceiver,
+		TrackInclusion:  job.TrackInclusion,
+		ContractAddress: job.ContractAddress,
+	}
+	if job.ContractTxValue != "" {
+		value, ok := new(big.Int).SetString(job.ContractTxValue, 10)
+		if !ok {
+			return nil, fmt.Errorf("failed to parse SC tx value from input %s", job.ContractTxValue)
+		}
+		tf.ContractTxValue = value
+	}
+
+	var err error
+	tf.Accounts, err = accounts.GenerateAccountsFrom(job.AccountOffset, job.NumAcc, job.Seed)
+	if err != nil {
+		return nil, err
+	}
+	tf.EvrClient, err = node.DialPool(job.RPCEndpoints, job.RPCPolicy)
+	if err != nil {
+		return nil, err
+	}
+	var chainID *big.Int
+	if job.ChainID != 0 {
+		chainID = new(big.Int).SetUint64(job.ChainID)
+	}
+	tf.Signer, err = node.NewSigner(tf.EvrClient, chainID, job.LegacySigner)
+	if err != nil {
+		return nil, err
+	}
+	return tf, nil
+}
+
+func runJob(job Job) (Result, error) {
+	if job.NumAcc == 0 {
+		return Result{}, nil
+	}
+	tf, err := NewTxFloodFromJob(job)
+	if err != nil {
+		return Result{}, err
+	}
+	fmt.Printf("Waiting until %s to flood from accounts [%d-%d)\n", job.StartAt.Format(time.RFC3339Nano), job.AccountOffset, job.AccountOffset+job.NumAcc)
+	time.Sleep(time.Until(job.StartAt))
+	err = tf.Start()
+	return tf.Result(), err
+}
+
+// Agent runs jobs received from a coordinator, one at a time. A job carries the seed of its accounts, so the
+// agent only accepts jobs sent with its token.
+type Agent struct {
+	mu    *sync.Mutex
+	busy  bool
+	run   func(job Job) (Result, error)
+	token string
+}
+
+// NewAgent returns an agent that floods the network when it receives a job sent with token
+func NewAgent(token string) (*Agent, error) {
+	if token == "" {
+		return nil, errors.New("agent token is required")
+	}
+	return &Agent{
+		mu:    &sync.Mutex{},
+		run:   runJob,
+		token: token,
+	}, nil
+}
+
+// NewAgentServer returns a server of agent on addr that gives up on a job after timeout
+func NewAgentServer(addr string, agent *Agent, timeout time.Duration) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle(AgentRunPath, agent)
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: agentReadTimeout,
+		ReadTimeout:       agentReadTimeout,
+		WriteTimeout:      timeout,
+	}
+}
+
+// ServeHTTP runs the job posted by a coordinator and responds with its AgentResult
+func (a *Agent) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+	var job Job
+	if err := json.NewDecoder(r.Body).Decode(&job); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	a.mu.Lock()
+	if a.busy {
+		a.mu.Unlock()
+		http.Error(w, "agent is running another job", http.StatusConflict)
+		return
+	}
+	a.busy = true
+	a.mu.Unlock()
+	defer func() {
+		a.mu.Lock()
+		a.busy = false
+		a.mu.Unlock()
+	}()
+
+	result, err := a.run(job)
+	res := AgentResult{Job: job, Result: result}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// Coordinator splits a flood between agents, starts them at the same time and merges their results
+type Coordinator struct {
+	Agents     []string
+	Job        Job
+	StartDelay time.Duration
+	client     *http.Client
+	// Token authenticates the coordinator to the agents
+	Token string
+	// deploySlot deploys the contract written by all agents in StorageMode
+	deploySlot func(job Job) (*common.Address, error)
+}
+
+// NewCoordinator returns a coordinator that splits job between agents, sending it with token and giving up
+// on an agent after timeout
+func NewCoordinator(agents []string, job Job, startDelay time.Duration, token string, timeout time.Duration) *Coordinator {
+	return &Coordinator{
+		Agents:     agents,
+		Job:        job,
+		StartDelay: startDelay,
+		client:     &http.Client{Timeout: timeout},
+		Token:      token,
+		deploySlot: deploySlotContract,
+	}
+}
+
+// deploySlotContract deploys the contract written by StorageMode txs from the first account of job
+func deploySlotContract(job Job) (*common.Address, error) {
+	job.NumAcc = 1
+	tf, err := NewTxFloodFromJob(job)
+	if err != nil {
+		return nil, err
+	}
+	if err := tf.prepareSigner(); err != nil {
+		return nil, err
+	}
+	return tf.deployContract(slotContractBin)
+}
+
+// Report merges the results of all agents
+type Report struct {
+	Agents []AgentResult
+	Total  Result
+	Policy node.Policy
+}
+
+// Print prints the report on console view
+func (r *Report) Print() {
+	fmt.Println("-----------Agent Stats----------------")
+	for _, res := range r.Agents {
+		fmt.Printf("%s accounts [%d-%d): sent %d, failed %d, elapsed %s, TPS %.4f\n", res.Agent,
+			res.Job.AccountOffset, res.Job.AccountOffset+res.Job.NumAcc,
+			res.Result.Sent, res.Result.Failed, res.Result.Elapsed, res.Result.TPS())
+		if res.Error != "" {
+			fmt.Printf("%s error: %s\n", res.Agent, res.Error)
+		}
+	}
+	fmt.Println("-----------General Stats----------------")
+	fmt.Println("Agents:", len(r.Agents))
+	fmt.Println("Total Sent:", r.Total.Sent)
+	fmt.Println("Total Failed:", r.Total.Failed)
+	fmt.Println("Elapsed:", r.Total.Elapsed)
+	fmt.Println("Random seed:", r.Total.RandSeed)
+	fmt.Println("=> TPS:", r.Total.TPS())
+	node.PrintEndpointStats(r.Policy, r.Total.Endpoints)
+}
+
+// Run sends every agent its share of the job and waits for all of them to finish
+func (c *Coordinator) Run() (*Report, error) {
+	if len(c.Agents) == 0 {
+		return nil, errors.New("no agent to coordinate")
+	}
+	job := c.Job
+	if job.HotSpot > 0 && job.HotReceiver == nil && job.NumAcc > 0 {
+		accs, err := accounts.GenerateAccountsFrom(job.AccountOffset, 1, job.Seed)
+		if err != nil {
+			return nil, err
+		}
+		job.HotReceiver = &accs[0].Address
+	}
+	// all agents write to the same contract so that they contend for the same slot
+	if job.FloodMode == StorageMode && job.ContractAddress == nil && job.NumAcc > 0 {
+		addr, err := c.deploySlot(job)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to deploy slot contract")
+		}
+		fmt.Printf("Slot contract deployed at %s\n", addr.Hex())
+		job.ContractAddress = addr
+	}
+	if job.RandSeed == 0 {
+		job.RandSeed = time.Now().UnixNano()
+	}
+	fmt.Printf("Random seed: %d\n", job.RandSeed)
+	job.StartAt = time.Now().Add(c.StartDelay)
+	jobs := splitJob(job, len(c.Agents))
+
+	var (
+		wg      sync.WaitGroup
+		results = make([]AgentResult, len(jobs))
+	)
+	for i := range jobs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = c.sendJob(c.Agents[i], jobs[i])
+		}(i)
+	}
+	wg.Wait()
+
+	report := mergeResults(results)
+	report.Policy = c.Job.RPCPolicy
+	report.Total.RandSeed = job.RandSeed
+	var failed []string
+	for _, res := range results {
+		if res.Error != "" {
+			failed = append(failed, res.Agent)
+		}
+	}
+	if len(failed) != 0 {
+		return report, fmt.Errorf("agents %s failed", strings.Join(failed, ", "))
+	}
+	return report, nil
+}
+
+func (c *Coordinator) sendJob(agent string, job Job) AgentResult {
+	res := AgentResult{Agent: agent, Job: job}
+	body, err := json.Marshal(job)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	url := agent
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		url = "http://" + url
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(url, "/")+AgentRunPath, bytes.NewReader(body))
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		res.Error = fmt.Sprintf("agent responded with status %s", resp.Status)
+		return res
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		res.Error = err.Error()
+	}
+	res.Agent = agent
+	return res
+}
+
+// splitJob divides the accounts and rate of a job into n disjoint shares
+func splitJob(job Job, n int) []Job {
+	var (
+		jobs   = make([]Job, n)
+		offset = job.AccountOffset
+	)
+	for i := 0; i < n; i++ {
+		share := job
+		share.AccountOffset = offset
+		share.NumAcc = job.NumAcc / n
+		if i < job.NumAcc%n {
+			share.NumAcc++
+		}
+		if job.Rate > 0 {
+			share.Rate = job.Rate / n
+			if i < job.Rate%n {
+				share.Rate++
+			}
+			// a rate of 0 would mean unlimited
+			if share.Rate == 0 {
+				share.Rate = 1
+			}
+		}
+		offset += share.NumAcc
+		jobs[i] = share
+	}
+	return jobs
+}
+
+func mergeResults(results []AgentResult) *Report {
+	var (
+		report    = &Report{Agents: results}
+		endpoints = make(map[string]int)
+	)
+	for _, res := range results {
+		report.Total.Sent += res.Result.Sent
+		report.Total.Failed += res.Result.Failed
+		if res.Result.Elapsed > report.Total.Elapsed {
+			report.Total.Elapsed = res.Result.Elapsed
+		}
+		for _, s := range res.Result.Endpoints {
+			i, ok := endpoints[s.URL]
+			if !ok {
+				endpoints[s.URL] = len(report.Total.Endpoints)
+				report.Total.Endpoints = append(report.Total.Endpoints, s)
+				continue
+			}
+			total := &report.Total.Endpoints[i]
+			// weight the average latency of every agent by its number of requests
+			if requests := total.Requests + s.Requests; requests != 0 {
+				total.AvgLatency = time.Duration((uint64(total.AvgLatency)*total.Requests + uint64(s.AvgLatency)*s.Requests) / requests)
+			}
+			total.Requests += s.Requests
+			total.Errors += s.Errors
+			total.Failovers += s.Failovers
+		}
+	}
+	return report
+}
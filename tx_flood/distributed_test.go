@@ -0,0 +1,94 @@
+package tx_flood
+
+import (
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+)
+
+func TestSplitJob(t *testing.T) {
+	jobs := splitJob(Job{NumAcc: 10, Rate: 5}, 3)
+	assert.Len(t, jobs, 3)
+
+	var (
+		offset int
+		rate   int
+	)
+	for _, job := range jobs {
+		assert.Equal(t, offset, job.AccountOffset)
+		offset += job.NumAcc
+		rate += job.Rate
+	}
+	assert.Equal(t, 10, offset)
+	assert.Equal(t, 5, rate)
+	assert.Equal(t, []int{4, 3, 3}, []int{jobs[0].NumAcc, jobs[1].NumAcc, jobs[2].NumAcc})
+
+	// every agent keeps a limited rate
+	for _, job := range splitJob(Job{NumAcc: 4, Rate: 2}, 4) {
+		assert.Equal(t, 1, job.Rate)
+	}
+}
+
+func TestCoordinator_Run(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		startAt   []time.Time
+		contracts []*common.Address
+		agents    []string
+	)
+	for i := 0; i < 2; i++ {
+		agent, err := NewAgent("secret")
+		require.NoError(t, err)
+		agent.run = func(job Job) (Result, error) {
+			mu.Lock()
+			startAt = append(startAt, job.StartAt)
+			contracts = append(contracts, job.ContractAddress)
+			mu.Unlock()
+			return Result{Sent: uint64(job.NumAcc * job.NumTxPerAcc), Elapsed: time.Duration(job.NumAcc) * time.Second}, nil
+		}
+		server := httptest.NewServer(agent)
+		defer server.Close()
+		agents = append(agents, server.URL)
+	}
+
+	coordinator := NewCoordinator(agents, Job{NumAcc: 5, NumTxPerAcc: 2}, time.Second, "secret", time.Minute)
+	report, err := coordinator.Run()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), report.Total.Sent)
+	assert.Equal(t, 3*time.Second, report.Total.Elapsed)
+	assert.Len(t, startAt, 2)
+	assert.True(t, startAt[0].Equal(startAt[1]))
+
+	// agents write the storage slot of the same contract, deployed once
+	var (
+		deployed = 0
+		slot     = common.HexToAddress("0x5107")
+	)
+	contracts = nil
+	coordinator = NewCoordinator(agents, Job{NumAcc: 5, NumTxPerAcc: 2, FloodMode: StorageMode}, 0, "secret", time.Minute)
+	coordinator.deploySlot = func(job Job) (*common.Address, error) {
+		deployed++
+		return &slot, nil
+	}
+	_, err = coordinator.Run()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deployed)
+	assert.Equal(t, []*common.Address{&slot, &slot}, contracts)
+
+	// agents refuse jobs sent with another token
+	startAt = nil
+	coordinator = NewCoordinator(agents, Job{NumAcc: 5, NumTxPerAcc: 2}, 0, "guess", time.Minute)
+	report, err = coordinator.Run()
+	assert.Error(t, err)
+	assert.Contains(t, report.Agents[0].Error, "401")
+	assert.Empty(t, startAt)
+
+	_, err = NewAgent("")
+	assert.Error(t, err)
+}
@@ -1,12 +1,21 @@
 package tx_flood
 
 import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
 	"time"
 
+	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/urfave/cli"
 
 	"github.com/evrynet-official/evrynet-tools/accounts"
+	"github.com/evrynet-official/evrynet-tools/lib/metrics"
 	"github.com/evrynet-official/evrynet-tools/lib/node"
+	"github.com/evrynet-official/evrynet-tools/lib/results"
+	"github.com/evrynet-official/evrynet-tools/tx_metric"
 )
 
 const (
@@ -14,6 +23,32 @@ const (
 	floodModeFlag                  = "flood-mode"
 	continuousFlooding             = "continuous"
 	sleepDurationBetweenFloodsFlag = "sleep-duration"
+	contractTxValueFlag            = "sc-tx-value"
+	receiptTimeoutFlag             = "receipt-timeout"
+	rateFlag                       = "rate"
+	agentsFlag                     = "agents"
+	startDelayFlag                 = "start-delay"
+	listenAddrFlag                 = "listen"
+	agentTokenFlag                 = "token"
+	agentTimeoutFlag               = "agent-timeout"
+	contractAddressFlag            = "contract"
+	outFileFlag                    = "out"
+	inFileFlag                     = "in"
+	workersFlag                    = "workers"
+	speedupFlag                    = "speedup"
+	keepValueFlag                  = "keep-value"
+	advAccountsFlag                = "adv-accounts"
+	roundsFlag                     = "rounds"
+	maxPendingFlag                 = "max-pending"
+	maxQueuedFlag                  = "max-queued"
+	maxBumpFlag                    = "max-bump"
+	randSeedFlag                   = "rand-seed"
+	adaptiveFlag                   = "adaptive"
+	poolHighFlag                   = "pool-high"
+	poolLowFlag                    = "pool-low"
+	maxErrorRatioFlag              = "max-error-ratio"
+	adaptIntervalFlag              = "adapt-interval"
+	hotSpotFlag                    = "hot-spot"
 )
 
 // NewTxFloodFlags return flags to tx flood
@@ -25,7 +60,7 @@ func NewTxFloodFlags() []cli.Flag {
 			Value: 1,
 		}, cli.IntFlag{
 			Name:  floodModeFlag,
-			Usage: "Flood mode when send Tx: 0: Random, 1: Normal Tx, 2: Tx with SC",
+			Usage: "Flood mode when send Tx: 0: Random, 1: Normal Tx, 2: Tx with SC, 3: Tx writing a storage slot",
 			Value: 0,
 		},
 		cli.BoolFlag{
@@ -37,20 +72,92 @@ func NewTxFloodFlags() []cli.Flag {
 			Usage: "Time to sleep after each batch of numAccount*numTxPerAcc flooding",
 			Value: time.Second,
 		},
+		cli.StringFlag{
+			Name:  contractTxValueFlag,
+			Usage: "The amount (wei) sent along with each setNumber call to SC",
+			Value: "0",
+		},
+		cli.DurationFlag{
+			Name:  receiptTimeoutFlag,
+			Usage: "Time to wait for receipts of Txs sent to SC after flooding",
+			Value: defaultReceiptTimeout,
+		},
+		cli.IntFlag{
+			Name:  rateFlag,
+			Usage: "Maximum number of txs sent per second by all accounts, 0 means unlimited",
+			Value: 0,
+		},
+		cli.StringFlag{
+			Name:  contractAddressFlag,
+			Usage: "Address of a deployed SC of the flood mode to call instead of deploying a new one",
+		},
+		cli.Int64Flag{
+			Name:  randSeedFlag,
+			Usage: "Seed of the recipients, amounts and kinds of the txs, printed at start to repeat a run, 0 means a new seed",
+			Value: 0,
+		},
+		cli.Float64Flag{
+			Name:  hotSpotFlag,
+			Usage: "Fraction of normal txs sent to the first account, or of storage txs written to one slot; when set, even to 0, the time until hot-spot and other txs are mined is reported",
+			Value: 0,
+		},
+		cli.BoolFlag{
+			Name:  adaptiveFlag,
+			Usage: "Lower the rate when the txpool fills up or txs fail and raise it up to --rate when the txpool drains",
+		},
+		cli.Uint64Flag{
+			Name:  poolHighFlag,
+			Usage: "Number of pending and queued txs above which the adaptive rate is halved",
+			Value: 4096,
+		},
+		cli.Uint64Flag{
+			Name:  poolLowFlag,
+			Usage: "Number of pending and queued txs below which the adaptive rate is raised",
+			Value: 1024,
+		},
+		cli.Float64Flag{
+			Name:  maxErrorRatioFlag,
+			Usage: "Ratio of failed txs between two polls above which the adaptive rate is halved",
+			Value: 0.1,
+		},
+		cli.DurationFlag{
+			Name:  adaptIntervalFlag,
+			Usage: "Time between two polls of the txpool status in adaptive mode",
+			Value: 2 * time.Second,
+		},
 	}
 	flags = append(flags, node.NewEvrynetNodeFlags()...)
-	return flags
+	flags = append(flags, metrics.NewMetricsFlags()...)
+	return append(flags, node.NewSignerFlags()...)
 }
 
 // NewTxFloodFromFlags will send tx flood
 func NewTxFloodFromFlags(ctx *cli.Context) (tf *TxFlood, err error) {
 	tf = &TxFlood{
-		NumAcc:        ctx.Int(accounts.NumAccountsFlag.Name),
-		NumTxPerAcc:   ctx.Int(numTxPerAccFlag),
-		Seed:          ctx.String(accounts.SeedFlag.Name),
-		FloodMode:     FloodMode(ctx.Int(floodModeFlag)),
-		Continuous:    ctx.Bool(continuousFlooding),
-		SleepInterval: ctx.Duration(sleepDurationBetweenFloodsFlag),
+		NumAcc:         ctx.Int(accounts.NumAccountsFlag.Name),
+		NumTxPerAcc:    ctx.Int(numTxPerAccFlag),
+		Seed:           ctx.String(accounts.SeedFlag.Name),
+		FloodMode:      FloodMode(ctx.Int(floodModeFlag)),
+		Continuous:     ctx.Bool(continuousFlooding),
+		SleepInterval:  ctx.Duration(sleepDurationBetweenFloodsFlag),
+		ReceiptTimeout: ctx.Duration(receiptTimeoutFlag),
+		Rate:           ctx.Int(rateFlag),
+		RandSeed:       ctx.Int64(randSeedFlag),
+	}
+
+	value := ctx.String(contractTxValueFlag)
+	contractTxValue, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse SC tx value from input %s", value)
+	}
+	tf.ContractTxValue = contractTxValue
+
+	if addr := ctx.String(contractAddressFlag); addr != "" {
+		if !common.IsHexAddress(addr) {
+			return nil, fmt.Errorf("invalid SC address %s", addr)
+		}
+		contractAddr := common.HexToAddress(addr)
+		tf.ContractAddress = &contractAddr
 	}
 
 	tf.Accounts, err = accounts.GenerateAccounts(tf.NumAcc, tf.Seed)
@@ -58,9 +165,366 @@ func NewTxFloodFromFlags(ctx *cli.Context) (tf *TxFlood, err error) {
 		return nil, err
 	}
 
-	tf.EvrClient, err = node.NewEvrynetClientFromFlags(ctx)
+	tf.EvrClient, err = node.NewPoolFromFlags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tf.Signer, err = node.SignerFromFlags(ctx, tf.EvrClient)
 	if err != nil {
 		return nil, err
 	}
+
+	tf.HotSpot = ctx.Float64(hotSpotFlag)
+	if tf.HotSpot < 0 || tf.HotSpot > 1 {
+		return nil, fmt.Errorf("--%s must be between 0 and 1", hotSpotFlag)
+	}
+	tf.TrackInclusion = ctx.IsSet(hotSpotFlag)
+
+	if ctx.Bool(adaptiveFlag) {
+		if tf.Rate <= 0 {
+			return nil, fmt.Errorf("--%s requires --%s, the rate to start at", adaptiveFlag, rateFlag)
+		}
+		tf.Backpressure = &Backpressure{
+			EvrClient:     tf.EvrClient,
+			MaxRate:       tf.Rate,
+			High:          ctx.Uint64(poolHighFlag),
+			Low:           ctx.Uint64(poolLowFlag),
+			MaxErrorRatio: ctx.Float64(maxErrorRatioFlag),
+			Interval:      ctx.Duration(adaptIntervalFlag),
+		}
+	}
 	return tf, nil
 }
+
+// newAgentAuthFlags return the flags shared by agents and coordinators
+func newAgentAuthFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:   agentTokenFlag,
+			Usage:  "Token shared by the coordinator and its agents, required",
+			EnvVar: "TX_FLOOD_AGENT_TOKEN",
+		},
+		cli.DurationFlag{
+			Name:  agentTimeoutFlag,
+			Usage: "Time an agent has to run a job",
+			Value: time.Hour,
+		},
+	}
+}
+
+// NewAgentFlags return flags to run a tx flood agent
+func NewAgentFlags() []cli.Flag {
+	return append([]cli.Flag{
+		cli.StringFlag{
+			Name:  listenAddrFlag,
+			Usage: "Address the agent listens on for jobs from a coordinator",
+			Value: ":7070",
+		},
+	}, newAgentAuthFlags()...)
+}
+
+// NewAgentServerFromFlags returns the server of an agent described by flags
+func NewAgentServerFromFlags(ctx *cli.Context) (*http.Server, error) {
+	agent, err := NewAgent(ctx.String(agentTokenFlag))
+	if err != nil {
+		return nil, err
+	}
+	return NewAgentServer(ctx.String(listenAddrFlag), agent, ctx.Duration(agentTimeoutFlag)), nil
+}
+
+// NewCoordinatorFlags return flags to coordinate a distributed tx flood
+func NewCoordinatorFlags() []cli.Flag {
+	flags := []cli.Flag{
+		cli.StringSliceFlag{
+			Name:  agentsFlag,
+			Usage: "Address of an agent (host:port), repeat the flag for each agent",
+		},
+		cli.DurationFlag{
+			Name:  startDelayFlag,
+			Usage: "Time given to agents to prepare before all of them start flooding",
+			Value: 3 * time.Second,
+		},
+	}
+	flags = append(flags, newAgentAuthFlags()...)
+	flags = append(flags, accounts.NewAccountsFlags()...)
+	flags = append(flags, results.NewResultsFlags()...)
+	return append(flags, NewTxFloodFlags()...)
+}
+
+// NewCoordinatorFromFlags returns a coordinator splitting the flood described by flags between agents
+func NewCoordinatorFromFlags(ctx *cli.Context) (*Coordinator, error) {
+	if ctx.Bool(continuousFlooding) {
+		return nil, errors.New("continuous flooding is not supported by coordinator")
+	}
+	// agents flood at the rate of their share, the backpressure of a single process is not distributed
+	for _, name := range []string{adaptiveFlag, poolHighFlag, poolLowFlag, maxErrorRatioFlag, adaptIntervalFlag} {
+		if ctx.IsSet(name) {
+			return nil, fmt.Errorf("--%s is not supported by coordinator", name)
+		}
+	}
+	if ctx.IsSet(sleepDurationBetweenFloodsFlag) {
+		return nil, fmt.Errorf("--%s is only used by continuous flooding, which is not supported by coordinator", sleepDurationBetweenFloodsFlag)
+	}
+	job := Job{
+		NumAcc:          ctx.Int(accounts.NumAccountsFlag.Name),
+		NumTxPerAcc:     ctx.Int(numTxPerAccFlag),
+		Seed:            ctx.String(accounts.SeedFlag.Name),
+		FloodMode:       FloodMode(ctx.Int(floodModeFlag)),
+		Rate:            ctx.Int(rateFlag),
+		ContractTxValue: ctx.String(contractTxValueFlag),
+		ReceiptTimeout:  ctx.Duration(receiptTimeoutFlag),
+		RPCEndpoints:    node.EndpointsFromFlags(ctx),
+		RandSeed:        ctx.Int64(randSeedFlag),
+		HotSpot:         ctx.Float64(hotSpotFlag),
+		TrackInclusion:  ctx.IsSet(hotSpotFlag),
+	}
+	policy, err := node.ParsePolicy(node.PolicyFromFlags(ctx))
+	if err != nil {
+		return nil, err
+	}
+	job.RPCPolicy = policy
+	chainID, err := node.ChainIDFromFlags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if chainID != nil {
+		job.ChainID = chainID.Uint64()
+	}
+	job.LegacySigner = node.LegacySignerFromFlags(ctx)
+	if addr := ctx.String(contractAddressFlag); addr != "" {
+		if !common.IsHexAddress(addr) {
+			return nil, fmt.Errorf("invalid SC address %s", addr)
+		}
+		contractAddr := common.HexToAddress(addr)
+		job.ContractAddress = &contractAddr
+	}
+	token := ctx.String(agentTokenFlag)
+	if token == "" {
+		return nil, errors.New("agent token is required")
+	}
+	return NewCoordinator(ctx.StringSlice(agentsFlag), job, ctx.Duration(startDelayFlag), token, ctx.Duration(agentTimeoutFlag)), nil
+}
+
+// NewPresignFlags return flags to presign txs
+func NewPresignFlags() []cli.Flag {
+	flags := []cli.Flag{
+		cli.StringFlag{
+			Name:  outFileFlag,
+			Usage: "File the signed txs are written to",
+			Value: "signed_txs.txt",
+		},
+	}
+	flags = append(flags, accounts.NewAccountsFlags()...)
+	return append(flags, NewTxFloodFlags()...)
+}
+
+// OutFileFromFlags returns the file presigned txs are written to
+func OutFileFromFlags(ctx *cli.Context) string {
+	return ctx.String(outFileFlag)
+}
+
+// NewReplayFlags return flags to replay presigned txs
+func NewReplayFlags() []cli.Flag {
+	flags := []cli.Flag{
+		cli.StringFlag{
+			Name:  inFileFlag,
+			Usage: "File of signed txs written by presign",
+			Value: "signed_txs.txt",
+		},
+		cli.IntFlag{
+			Name:  rateFlag,
+			Usage: "Number of txs sent per second, 0 means as fast as possible",
+			Value: 0,
+		},
+		cli.IntFlag{
+			Name:  workersFlag,
+			Usage: "Number of concurrent senders",
+			Value: 8,
+		},
+	}
+	flags = append(flags, results.NewResultsFlags()...)
+	return append(flags, node.NewEvrynetNodeFlags()...)
+}
+
+// NewReplayerFromFlags reads the presigned txs and returns a replayer pushing them at the rate of flags
+func NewReplayerFromFlags(ctx *cli.Context) (*Replayer, error) {
+	file, err := os.Open(ctx.String(inFileFlag))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	txs, err := ReadSignedTxs(file)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := node.NewPoolFromFlags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Replayer{
+		EvrClient: pool,
+		Txs:       txs,
+		Rate:      ctx.Int(rateFlag),
+		Workers:   ctx.Int(workersFlag),
+	}, nil
+}
+
+// NewMirrorFlags return flags to replay captured txs
+func NewMirrorFlags() []cli.Flag {
+	flags := []cli.Flag{
+		cli.StringFlag{
+			Name:  inFileFlag,
+			Usage: "File of txs written by tx_metric capture",
+			Value: "capture.jsonl",
+		},
+		accounts.SeedFlag,
+		cli.Float64Flag{
+			Name:  speedupFlag,
+			Usage: "Divides the time between txs, 1 keeps the original timing",
+			Value: 1,
+		},
+		cli.BoolFlag{
+			Name:  keepValueFlag,
+			Usage: "Send the original value along with each tx instead of zero",
+		},
+		cli.IntFlag{
+			Name:  workersFlag,
+			Usage: "Number of concurrent senders",
+			Value: 8,
+		},
+	}
+	flags = append(flags, node.NewEvrynetNodeFlags()...)
+	return append(flags, node.NewSignerFlags()...)
+}
+
+// NewMirrorFromFlags reads the captured txs and returns a mirror replaying them with the options of flags
+func NewMirrorFromFlags(ctx *cli.Context) (*Mirror, error) {
+	file, err := os.Open(ctx.String(inFileFlag))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	captured, err := tx_metric.ReadCapture(file)
+	if err != nil {
+		return nil, err
+	}
+	speedup := ctx.Float64(speedupFlag)
+	if speedup <= 0 {
+		return nil, fmt.Errorf("invalid speedup %v", speedup)
+	}
+	pool, err := node.NewPoolFromFlags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := node.SignerFromFlags(ctx, pool)
+	if err != nil {
+		return nil, err
+	}
+	return &Mirror{
+		EvrClient: pool,
+		Signer:    signer,
+		Captured:  captured,
+		Seed:      ctx.String(accounts.SeedFlag.Name),
+		Speedup:   speedup,
+		KeepValue: ctx.Bool(keepValueFlag),
+		Workers:   ctx.Int(workersFlag),
+	}, nil
+}
+
+// NewAdversaryFlags return flags to send malformed txs alongside an honest flood
+func NewAdversaryFlags() []cli.Flag {
+	flags := []cli.Flag{
+		cli.IntFlag{
+			Name:  advAccountsFlag,
+			Usage: "Number of accounts sending malformed txs, generated after the accounts of the honest flood",
+			Value: 1,
+		},
+		cli.IntFlag{
+			Name:  roundsFlag,
+			Usage: "Number of times every adversarial account sends every kind of malformed tx",
+			Value: 1,
+		},
+	}
+	flags = append(flags, accounts.NewAccountsFlags()...)
+	return append(flags, NewTxFloodFlags()...)
+}
+
+// NewAdversaryFromFlags returns an adversary running alongside the honest flood of flags, if num is not 0
+func NewAdversaryFromFlags(ctx *cli.Context) (*Adversary, error) {
+	if ctx.Bool(continuousFlooding) {
+		return nil, errors.New("continuous flooding is not supported by adversarial mode")
+	}
+	var (
+		numAcc = ctx.Int(accounts.NumAccountsFlag.Name)
+		seed   = ctx.String(accounts.SeedFlag.Name)
+	)
+	advAccounts, err := accounts.GenerateAccountsFrom(numAcc, ctx.Int(advAccountsFlag), seed)
+	if err != nil {
+		return nil, err
+	}
+	honest, err := NewTxFloodFromFlags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	adversary := &Adversary{
+		EvrClient: honest.EvrClient,
+		Accounts:  advAccounts,
+		Rounds:    ctx.Int(roundsFlag),
+		Signer:    honest.Signer,
+	}
+	if numAcc > 0 {
+		adversary.Honest = honest
+	}
+	return adversary, nil
+}
+
+// NewProbeFlags return flags to probe the txpool limits of a node
+func NewProbeFlags() []cli.Flag {
+	flags := []cli.Flag{
+		accounts.SeedFlag,
+		cli.IntFlag{
+			Name:  maxPendingFlag,
+			Usage: "Most txs sent in nonce order from one account",
+			Value: 8192,
+		},
+		cli.IntFlag{
+			Name:  maxQueuedFlag,
+			Usage: "Most txs sent after a nonce gap from one account",
+			Value: 2048,
+		},
+		cli.IntFlag{
+			Name:  maxBumpFlag,
+			Usage: "Highest gas price increase tried for a replacement, in percent",
+			Value: 100,
+		},
+	}
+	flags = append(flags, node.NewEvrynetNodeFlags()...)
+	return append(flags, node.NewSignerFlags()...)
+}
+
+// NewProbeFromFlags returns a probe of the first RPC endpoint, sending from the first 3 accounts of seed
+func NewProbeFromFlags(ctx *cli.Context) (*Probe, error) {
+	accs, err := accounts.GenerateAccounts(3, ctx.String(accounts.SeedFlag.Name))
+	if err != nil {
+		return nil, err
+	}
+	// the limits are those of a single node
+	pool, err := node.DialPool([]string{node.EndpointFromFlags(ctx)}, node.RoundRobin)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := node.SignerFromFlags(ctx, pool)
+	if err != nil {
+		return nil, err
+	}
+	return &Probe{
+		EvrClient:  pool,
+		Signer:     signer,
+		Accounts:   accs,
+		MaxPending: ctx.Int(maxPendingFlag),
+		MaxQueued:  ctx.Int(maxQueuedFlag),
+		MaxBump:    ctx.Int(maxBumpFlag),
+	}, nil
+}
@@ -0,0 +1,137 @@
+package tx_flood
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+
+	"github.com/evrynet-official/evrynet-tools/accounts"
+	"github.com/evrynet-official/evrynet-tools/lib/node"
+	"github.com/evrynet-official/evrynet-tools/tx_metric"
+)
+
+// Mirror replays txs captured from another network with generated accounts in place of the original senders,
+// keeping the time between txs
+type Mirror struct {
+	EvrClient *node.Pool
+	Captured  []tx_metric.CapturedTx
+	// Seed generates the accounts the original senders are mapped to
+	Seed string
+	// Speedup divides the time between txs, 1 keeps the original timing
+	Speedup float64
+	// KeepValue sends the original value along with each tx instead of zero
+	KeepValue bool
+	Workers   int
+	// Signer signs every tx, an EIP-155 signer of the chain id of the node is used when it is nil
+	Signer types.Signer
+
+	senders map[common.Address]*accounts.Account
+}
+
+// MapSenders maps every original sender, in order of first appearance, to a generated account
+// and returns the generated accounts, which need to be funded before the replay
+func (m *Mirror) MapSenders() ([]*accounts.Account, error) {
+	var senders []common.Address
+	m.senders = make(map[common.Address]*accounts.Account)
+	for _, tx := range m.Captured {
+		if _, ok := m.senders[tx.From]; !ok {
+			m.senders[tx.From] = nil
+			senders = append(senders, tx.From)
+		}
+	}
+	accs, err := accounts.GenerateAccounts(len(senders), m.Seed)
+	if err != nil {
+		return nil, err
+	}
+	for i, sender := range senders {
+		m.senders[sender] = accs[i]
+	}
+	return accs, nil
+}
+
+// Resign signs the captured txs with the mapped accounts, using the pending nonces of the accounts.
+// Transfers between two captured senders go to the mapped recipient, and calls to a contract created by a
+// captured tx go to the address the mirrored creation deploys it at. Other recipients are kept.
+func (m *Mirror) Resign() ([]*types.Transaction, []time.Duration, error) {
+	if m.senders == nil {
+		if _, err := m.MapSenders(); err != nil {
+			return nil, nil, err
+		}
+	}
+	var (
+		txs     = make([]*types.Transaction, 0, len(m.Captured))
+		offsets = make([]time.Duration, 0, len(m.Captured))
+		nonces  = make(map[common.Address]uint64)
+		// created maps the contracts created by captured txs to the contracts created by their mirrors
+		created = make(map[common.Address]common.Address)
+		speedup = m.Speedup
+	)
+	if speedup <= 0 {
+		speedup = 1
+	}
+	if m.Signer == nil {
+		signer, err := node.NewSigner(m.EvrClient, nil, false)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to get chain id")
+		}
+		m.Signer = signer
+	}
+	for _, acc := range m.senders {
+		nonce, err := m.EvrClient.PendingNonceAt(context.Background(), acc.Address)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to get nonce of %s", acc.Address.Hex())
+		}
+		nonces[acc.Address] = nonce
+	}
+
+	for _, captured := range m.Captured {
+		var (
+			acc   = m.senders[captured.From]
+			nonce = nonces[acc.Address]
+			value = common.Big0
+			tx    *types.Transaction
+		)
+		if m.KeepValue && captured.Value != nil {
+			value = captured.Value.ToInt()
+		}
+		switch {
+		case captured.To == nil:
+			tx = types.NewContractCreation(nonce, value, captured.Gas, captured.GasPrice.ToInt(), captured.Data)
+			created[crypto.CreateAddress(captured.From, captured.Nonce)] = crypto.CreateAddress(acc.Address, nonce)
+		case m.senders[*captured.To] != nil:
+			tx = types.NewTransaction(nonce, m.senders[*captured.To].Address, value, captured.Gas, captured.GasPrice.ToInt(), captured.Data)
+		case created[*captured.To] != (common.Address{}):
+			tx = types.NewTransaction(nonce, created[*captured.To], value, captured.Gas, captured.GasPrice.ToInt(), captured.Data)
+		default:
+			tx = types.NewTransaction(nonce, *captured.To, value, captured.Gas, captured.GasPrice.ToInt(), captured.Data)
+		}
+		signed, err := types.SignTx(tx, m.Signer, acc.PriKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		txs = append(txs, signed)
+		offsets = append(offsets, time.Duration(float64(captured.Offset)/speedup))
+		nonces[acc.Address]++
+	}
+	return txs, offsets, nil
+}
+
+// Start re-signs the captured txs and sends each of them at its original offset divided by Speedup
+func (m *Mirror) Start() (Result, error) {
+	txs, offsets, err := m.Resign()
+	if err != nil {
+		return Result{}, err
+	}
+	replayer := &Replayer{
+		EvrClient: m.EvrClient,
+		Txs:       txs,
+		Offsets:   offsets,
+		Workers:   m.Workers,
+	}
+	return replayer.Start()
+}
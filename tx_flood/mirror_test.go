@@ -0,0 +1,68 @@
+package tx_flood
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+
+	"github.com/evrynet-official/evrynet-tools/lib/node"
+	"github.com/evrynet-official/evrynet-tools/tx_metric"
+)
+
+func TestMirror_Resign(t *testing.T) {
+	stub := &txStub{nonce: 3}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	pool, err := node.DialPool([]string{server.URL}, node.RoundRobin)
+	assert.NoError(t, err)
+
+	var (
+		alice    = common.HexToAddress("0x1")
+		bob      = common.HexToAddress("0x2")
+		external = common.HexToAddress("0x3")
+		// contract is created by alice in the captured range
+		contract = crypto.CreateAddress(alice, 7)
+		price    = (*hexutil.Big)(gasPrice)
+		value    = (*hexutil.Big)(common.Big1)
+	)
+	mirror := &Mirror{
+		EvrClient: pool,
+		Captured: []tx_metric.CapturedTx{
+			{Offset: 0, From: alice, To: &bob, Value: value, Gas: 21000, GasPrice: price},
+			{Offset: 2 * time.Second, From: bob, To: &external, Value: value, Gas: 21000, GasPrice: price},
+			{Offset: 4 * time.Second, From: alice, To: nil, Value: value, Gas: 90000, GasPrice: price, Data: []byte{0x60}, Nonce: 7},
+			{Offset: 4 * time.Second, From: bob, To: &contract, Value: value, Gas: 90000, GasPrice: price},
+		},
+		Seed:    "mirror",
+		Speedup: 2,
+	}
+	accs, err := mirror.MapSenders()
+	assert.NoError(t, err)
+	assert.Len(t, accs, 2)
+
+	txs, offsets, err := mirror.Resign()
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Duration{0, time.Second, 2 * time.Second, 2 * time.Second}, offsets)
+
+	var senders []common.Address
+	for _, tx := range txs {
+		from, err := node.TxSender(tx)
+		assert.NoError(t, err)
+		senders = append(senders, from)
+		assert.Equal(t, int64(0), tx.Value().Int64())
+	}
+	assert.Equal(t, []common.Address{accs[0].Address, accs[1].Address, accs[0].Address, accs[1].Address}, senders)
+	// transfers between captured senders stay between the mapped accounts
+	assert.Equal(t, accs[1].Address, *txs[0].To())
+	assert.Equal(t, external, *txs[1].To())
+	assert.Nil(t, txs[2].To())
+	// calls to a contract created in the captured range go to the mirrored contract
+	assert.Equal(t, crypto.CreateAddress(accs[0].Address, 4), *txs[3].To())
+	assert.Equal(t, []uint64{3, 3, 4, 4}, []uint64{txs[0].Nonce(), txs[1].Nonce(), txs[2].Nonce(), txs[3].Nonce()})
+}
@@ -0,0 +1,215 @@
+package tx_flood
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+
+	"github.com/evrynet-official/evrynet-tools/accounts"
+	"github.com/evrynet-official/evrynet-tools/lib/node"
+)
+
+// Presign signs NumTxPerAcc txs for every account, with nonces planned from the pending nonce of the account,
+// and writes them to w as one hex encoded RLP tx per line. Txs are interleaved between accounts so that a
+// replay spreads the load over all accounts while keeping the nonce order of each account.
+func (tf *TxFlood) Presign(w io.Writer) (int, error) {
+	var (
+		contractAddr = &common.Address{}
+		nonces       = make([]uint64, len(tf.Accounts))
+		rngs         = make([]*rand.Rand, len(tf.Accounts))
+		written      int
+		err          error
+	)
+	if err := tf.prepareSigner(); err != nil {
+		return 0, err
+	}
+	tf.prepareRand()
+	switch tf.FloodMode {
+	case DefaultMode, SmartContractMode:
+		if contractAddr, err = tf.prepareContract(); err != nil {
+			return 0, err
+		}
+	case StorageMode:
+		if contractAddr, err = tf.prepareSlotContract(); err != nil {
+			return 0, err
+		}
+	}
+	for i, acc := range tf.Accounts {
+		rngs[i] = tf.accountRand(acc)
+		if nonces[i], err = tf.EvrClient.PendingNonceAt(context.Background(), acc.Address); err != nil {
+			return 0, errors.Wrapf(err, "failed to get nonce of %s", acc.Address.Hex())
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	for n := 0; n < tf.NumTxPerAcc; n++ {
+		for i, acc := range tf.Accounts {
+			tx, err := tf.signTx(acc, nonces[i], *contractAddr, rngs[i])
+			if err != nil {
+				return written, err
+			}
+			raw, err := rlp.EncodeToBytes(tx)
+			if err != nil {
+				return written, err
+			}
+			if _, err := fmt.Fprintln(bw, hexutil.Encode(raw)); err != nil {
+				return written, err
+			}
+			nonces[i]++
+			written++
+		}
+	}
+	return written, bw.Flush()
+}
+
+// signTx returns a signed tx of the flood mode, picking the kind of tx randomly in DefaultMode
+func (tf *TxFlood) signTx(acc *accounts.Account, nonce uint64, contractAddr common.Address, rng *rand.Rand) (*types.Transaction, error) {
+	mode := tf.FloodMode
+	if mode == DefaultMode {
+		mode = NormalTxMode
+		if rng.Intn(2) == 1 {
+			mode = SmartContractMode
+		}
+	}
+	switch mode {
+	case NormalTxMode:
+		tx, _, err := tf.newNormalTx(acc, nonce, rng)
+		return tx, err
+	case SmartContractMode:
+		tx, _, err := tf.newSmartContractTx(acc, nonce, contractAddr, rng)
+		return tx, err
+	case StorageMode:
+		tx, _, err := tf.newStorageTx(acc, nonce, contractAddr, rng)
+		return tx, err
+	}
+	return nil, errors.New("not support for this flood mode")
+}
+
+// ReadSignedTxs reads the txs written by Presign
+func ReadSignedTxs(r io.Reader) ([]*types.Transaction, error) {
+	var (
+		txs     []*types.Transaction
+		scanner = bufio.NewScanner(r)
+		line    int
+	)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		raw, err := hexutil.Decode(text)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid tx at line %d", line)
+		}
+		tx := new(types.Transaction)
+		if err := rlp.DecodeBytes(raw, tx); err != nil {
+			return nil, errors.Wrapf(err, "invalid tx at line %d", line)
+		}
+		txs = append(txs, tx)
+	}
+	return txs, scanner.Err()
+}
+
+// Replayer pushes presigned txs to the network at a target rate
+type Replayer struct {
+	EvrClient *node.Pool
+	Txs       []*types.Transaction
+	// Rate is the number of txs offered per second, 0 means as fast as possible
+	Rate int
+	// Offsets, when set, is the time after the start at which each tx is sent, instead of following Rate
+	Offsets []time.Duration
+	// Workers is the number of concurrent senders, the txs of an account are always sent by the same worker
+	Workers int
+}
+
+// Start sends every tx and returns once all of them were sent
+func (r *Replayer) Start() (Result, error) {
+	var (
+		workers = r.Workers
+		queues  []chan *types.Transaction
+		wg      sync.WaitGroup
+		sent    uint64
+		failed  uint64
+	)
+	if workers <= 0 {
+		workers = 1
+	}
+	if r.Offsets != nil && len(r.Offsets) != len(r.Txs) {
+		return Result{}, fmt.Errorf("%d offsets for %d txs", len(r.Offsets), len(r.Txs))
+	}
+	for i := 0; i < workers; i++ {
+		queue := make(chan *types.Transaction, 1024)
+		queues = append(queues, queue)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for tx := range queue {
+				if err := r.EvrClient.SendTransaction(context.Background(), tx); err != nil {
+					atomic.AddUint64(&failed, 1)
+					fmt.Printf("failed to send tx %s nonce %d, error %s\n", tx.Hash().Hex(), tx.Nonce(), err)
+					continue
+				}
+				atomic.AddUint64(&sent, 1)
+			}
+		}()
+	}
+
+	// the sender of every tx is recovered before the clock starts
+	keys := make([]uint32, len(r.Txs))
+	for i, tx := range r.Txs {
+		keys[i] = senderShard(tx)
+	}
+
+	start := time.Now()
+	for i, tx := range r.Txs {
+		switch {
+		case r.Offsets != nil:
+			time.Sleep(time.Until(start.Add(r.Offsets[i])))
+		case r.Rate > 0:
+			// schedule each tx from the start instead of sleeping a fixed interval, so that delays do not add up
+			time.Sleep(time.Until(start.Add(time.Duration(i) * time.Second / time.Duration(r.Rate))))
+		}
+		queues[keys[i]%uint32(workers)] <- tx
+	}
+	for _, queue := range queues {
+		close(queue)
+	}
+	wg.Wait()
+
+	result := Result{
+		Sent:      sent,
+		Failed:    failed,
+		Elapsed:   time.Since(start),
+		Endpoints: r.EvrClient.Stats(),
+	}
+	if failed != 0 {
+		return result, fmt.Errorf("fail to send %d transactions", failed)
+	}
+	return result, nil
+}
+
+func senderShard(tx *types.Transaction) uint32 {
+	from, err := node.TxSender(tx)
+	if err != nil {
+		return 0
+	}
+	h := fnv.New32a()
+	_, _ = h.Write(from.Bytes())
+	return h.Sum32()
+}
@@ -0,0 +1,153 @@
+package tx_flood
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+
+	"github.com/evrynet-official/evrynet-tools/accounts"
+	"github.com/evrynet-official/evrynet-tools/lib/node"
+)
+
+// txStub is a JSON-RPC server answering the pending nonce with nonce and recording the raw txs sent to it.
+// Txs are rejected with the error of validate when it is set, and methods answers other calls.
+type txStub struct {
+	mu       sync.Mutex
+	nonce    uint64
+	txs      []*types.Transaction
+	validate func(tx *types.Transaction) error
+	methods  map[string]func() interface{}
+}
+
+func (s *txStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	var (
+		result interface{}
+		err    error
+	)
+	switch method := s.methods[req.Method]; {
+	case method != nil:
+		s.mu.Lock()
+		result = method()
+		s.mu.Unlock()
+	case req.Method == "eth_getTransactionCount":
+		result = hexutil.Uint64(s.nonce)
+	case req.Method == "eth_chainId":
+		result = hexutil.Uint64(1)
+	case req.Method == "eth_getBlockByNumber":
+		result = &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1), GasLimit: 8000000}
+	case req.Method == "eth_sendRawTransaction":
+		var raw hexutil.Bytes
+		_ = json.Unmarshal(req.Params[0], &raw)
+		tx := new(types.Transaction)
+		_ = rlp.DecodeBytes(raw, tx)
+		s.mu.Lock()
+		if s.validate != nil {
+			err = s.validate(tx)
+		}
+		if err == nil {
+			s.txs = append(s.txs, tx)
+		}
+		s.mu.Unlock()
+		result = tx.Hash()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "error": map[string]interface{}{"code": -32000, "message": err.Error()}})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+}
+
+func TestPresignReplay(t *testing.T) {
+	stub := &txStub{nonce: 5}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	pool, err := node.DialPool([]string{server.URL}, node.RoundRobin)
+	assert.NoError(t, err)
+
+	accs, err := accounts.GenerateAccounts(3, "presign")
+	assert.NoError(t, err)
+	tf := &TxFlood{
+		NumAcc:      3,
+		NumTxPerAcc: 4,
+		FloodMode:   NormalTxMode,
+		EvrClient:   pool,
+		Accounts:    accs,
+	}
+
+	var buf bytes.Buffer
+	n, err := tf.Presign(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 12, n)
+
+	txs, err := ReadSignedTxs(&buf)
+	assert.NoError(t, err)
+	assert.Len(t, txs, 12)
+	// signed for the chain id of the node
+	assert.True(t, txs[0].Protected())
+	assert.Equal(t, int64(1), txs[0].ChainId().Int64())
+
+	replayer := &Replayer{EvrClient: pool, Txs: txs, Rate: 1000, Workers: 2}
+	result, err := replayer.Start()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(12), result.Sent)
+
+	// every account sent its txs in nonce order starting from its pending nonce
+	next := make(map[common.Address]uint64)
+	for _, tx := range stub.txs {
+		from, err := node.TxSender(tx)
+		assert.NoError(t, err)
+		if _, ok := next[from]; !ok {
+			next[from] = stub.nonce
+		}
+		assert.Equal(t, next[from], tx.Nonce())
+		next[from]++
+	}
+	assert.Len(t, next, 3)
+}
+
+func TestPresign_RandSeed(t *testing.T) {
+	stub := &txStub{nonce: 5}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	pool, err := node.DialPool([]string{server.URL}, node.RoundRobin)
+	assert.NoError(t, err)
+
+	accs, err := accounts.GenerateAccounts(4, "presign")
+	assert.NoError(t, err)
+	presign := func(seed int64) string {
+		tf := &TxFlood{
+			NumAcc:      4,
+			NumTxPerAcc: 8,
+			FloodMode:   NormalTxMode,
+			EvrClient:   pool,
+			Accounts:    accs,
+			RandSeed:    seed,
+		}
+		var buf bytes.Buffer
+		_, err := tf.Presign(&buf)
+		assert.NoError(t, err)
+		return buf.String()
+	}
+
+	// the same seed signs the same txs, another seed picks other recipients and amounts
+	assert.Equal(t, presign(42), presign(42))
+	assert.NotEqual(t, presign(42), presign(43))
+}
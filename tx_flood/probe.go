@@ -0,0 +1,225 @@
+package tx_flood
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+
+	"github.com/evrynet-official/evrynet-tools/accounts"
+	"github.com/evrynet-official/evrynet-tools/lib/metrics"
+	"github.com/evrynet-official/evrynet-tools/lib/node"
+)
+
+// invalidGasPriceClass is the error class of a tx whose gas price is not the one of the network
+const invalidGasPriceClass = "invalid_gas_price"
+
+// Probe finds the txpool limits of a node by sending a growing queue of txs in nonce order from one account,
+// txs after a nonce gap from another and replacements at increasing gas prices from a third one
+type Probe struct {
+	EvrClient *node.Pool
+	// Accounts are the pending, queued and replacement accounts, in that order
+	Accounts []*accounts.Account
+	// MaxPending is the most txs sent in nonce order
+	MaxPending int
+	// MaxQueued is the most txs sent after a nonce gap
+	MaxQueued int
+	// MaxBump is the highest gas price increase tried for a replacement, in percent
+	MaxBump int
+	// Signer signs every tx, an EIP-155 signer of the chain id of the node is used when it is nil
+	Signer types.Signer
+}
+
+// ProbeReport is what a probe found out about the txpool of a node
+type ProbeReport struct {
+	PendingSent int
+	// PendingHeld is the most txs of the account found in the txpool at once
+	PendingHeld int
+	// PendingDropped is the number of accepted txs neither in the txpool nor mined
+	PendingDropped int
+	PendingErr     error
+	QueuedSent     int
+	QueuedHeld     int
+	QueuedDropped  int
+	QueuedErr      error
+	// MaxStatus is the most pending and queued txs of all accounts found in the txpool
+	MaxStatus node.TxpoolStatus
+	// ReplacementBump is the lowest gas price increase in percent accepted for a replacement, -1 if none was
+	ReplacementBump int
+	ReplacementErr  error
+	// FixedGasPrice is set when the node only accepts the gas price of the network, so that no tx can be
+	// replaced by a higher price
+	FixedGasPrice bool
+}
+
+// Start runs the probes one after another
+func (p *Probe) Start() (*ProbeReport, error) {
+	if len(p.Accounts) < 3 {
+		return nil, errors.New("probe needs 3 accounts")
+	}
+	report := &ProbeReport{ReplacementBump: -1}
+	if p.Signer == nil {
+		signer, err := node.NewSigner(p.EvrClient, nil, false)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get chain id")
+		}
+		p.Signer = signer
+	}
+	if err := p.probePending(p.Accounts[0], report); err != nil {
+		return report, err
+	}
+	if err := p.probeQueued(p.Accounts[1], report); err != nil {
+		return report, err
+	}
+	return report, p.probeReplacement(p.Accounts[2], report)
+}
+
+func (p *Probe) send(acc *accounts.Account, nonce uint64, price *big.Int) error {
+	tx := types.NewTransaction(nonce, acc.Address, common.Big0, 21000, price, nil)
+	signed, err := types.SignTx(tx, p.Signer, acc.PriKey)
+	if err != nil {
+		return err
+	}
+	return p.EvrClient.SendTransaction(context.Background(), signed)
+}
+
+// watch returns the number of pending and queued txs of acc and records the status of the txpool
+func (p *Probe) watch(acc *accounts.Account, report *ProbeReport) (int, int, error) {
+	ctx := context.Background()
+	status, err := p.EvrClient.TxpoolStatus(ctx, nil)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "failed to get txpool status")
+	}
+	if status.Pending > report.MaxStatus.Pending {
+		report.MaxStatus.Pending = status.Pending
+	}
+	if status.Queued > report.MaxStatus.Queued {
+		report.MaxStatus.Queued = status.Queued
+	}
+	pending, queued, err := p.EvrClient.TxpoolContentOf(ctx, nil, acc.Address)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "failed to get txpool content")
+	}
+	return pending, queued, nil
+}
+
+// probePending sends batches of 1, 2, 4... txs in nonce order until the node rejects one or MaxPending are sent
+func (p *Probe) probePending(acc *accounts.Account, report *ProbeReport) error {
+	start, err := p.EvrClient.PendingNonceAt(context.Background(), acc.Address)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get nonce of %s", acc.Address.Hex())
+	}
+	fmt.Printf("--- Probing pending txs from %s ...\n", acc.Address.Hex())
+	for batch := 1; report.PendingSent < p.MaxPending && report.PendingErr == nil; batch *= 2 {
+		for i := 0; i < batch && report.PendingSent < p.MaxPending; i++ {
+			if err := p.send(acc, start+uint64(report.PendingSent), gasPrice); err != nil {
+				report.PendingErr = err
+				break
+			}
+			report.PendingSent++
+		}
+		pending, _, err := p.watch(acc, report)
+		if err != nil {
+			return err
+		}
+		if pending > report.PendingHeld {
+			report.PendingHeld = pending
+		}
+		mined, err := p.EvrClient.NonceAt(context.Background(), acc.Address, nil)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get nonce of %s", acc.Address.Hex())
+		}
+		if dropped := report.PendingSent - pending - int(mined-start); dropped > report.PendingDropped {
+			report.PendingDropped = dropped
+		}
+		fmt.Printf("Sent %d txs, %d pending in txpool, %d mined\n", report.PendingSent, pending, mined-start)
+	}
+	return nil
+}
+
+// probeQueued sends txs after a nonce gap until the node rejects one or MaxQueued are sent,
+// then fills the gap so that the queued txs can be mined
+func (p *Probe) probeQueued(acc *accounts.Account, report *ProbeReport) error {
+	start, err := p.EvrClient.PendingNonceAt(context.Background(), acc.Address)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get nonce of %s", acc.Address.Hex())
+	}
+	fmt.Printf("--- Probing queued txs from %s ...\n", acc.Address.Hex())
+	for batch := 1; report.QueuedSent < p.MaxQueued && report.QueuedErr == nil; batch *= 2 {
+		for i := 0; i < batch && report.QueuedSent < p.MaxQueued; i++ {
+			if err := p.send(acc, start+1+uint64(report.QueuedSent), gasPrice); err != nil {
+				report.QueuedErr = err
+				break
+			}
+			report.QueuedSent++
+		}
+		_, queued, err := p.watch(acc, report)
+		if err != nil {
+			return err
+		}
+		if queued > report.QueuedHeld {
+			report.QueuedHeld = queued
+		}
+		if dropped := report.QueuedSent - queued; dropped > report.QueuedDropped {
+			report.QueuedDropped = dropped
+		}
+		fmt.Printf("Sent %d txs, %d queued in txpool\n", report.QueuedSent, queued)
+	}
+	return errors.Wrap(p.send(acc, start, gasPrice), "failed to fill the nonce gap")
+}
+
+// probeReplacement queues a tx after a nonce gap so that it is not mined, replaces it at increasing gas prices
+// until the node accepts one or rejects any gas price other than the one of the network, then fills the gap
+func (p *Probe) probeReplacement(acc *accounts.Account, report *ProbeReport) error {
+	start, err := p.EvrClient.PendingNonceAt(context.Background(), acc.Address)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get nonce of %s", acc.Address.Hex())
+	}
+	fmt.Printf("--- Probing replacements from %s ...\n", acc.Address.Hex())
+	if err := p.send(acc, start+1, gasPrice); err != nil {
+		return errors.Wrap(err, "failed to queue the tx to replace")
+	}
+	for bump := 1; bump <= p.MaxBump; bump++ {
+		price := new(big.Int).Div(new(big.Int).Mul(gasPrice, big.NewInt(int64(100+bump))), big.NewInt(100))
+		if err := p.send(acc, start+1, price); err != nil {
+			report.ReplacementErr = err
+			if metrics.ErrorClass(err) == invalidGasPriceClass {
+				report.FixedGasPrice = true
+				break
+			}
+			continue
+		}
+		report.ReplacementBump = bump
+		report.ReplacementErr = nil
+		break
+	}
+	return errors.Wrap(p.send(acc, start, gasPrice), "failed to fill the nonce gap")
+}
+
+// Print prints the limits found by the probe on console view
+func (r *ProbeReport) Print() {
+	fmt.Println("-----------Txpool Probe Stats----------------")
+	fmt.Printf("Pending: sent %d, most held %d, dropped %d\n", r.PendingSent, r.PendingHeld, r.PendingDropped)
+	if r.PendingErr != nil {
+		fmt.Printf("Pending: rejected tx %d with error %s\n", r.PendingSent+1, r.PendingErr)
+	}
+	fmt.Printf("Queued: sent %d, most held %d, dropped %d\n", r.QueuedSent, r.QueuedHeld, r.QueuedDropped)
+	if r.QueuedErr != nil {
+		fmt.Printf("Queued: rejected tx %d with error %s\n", r.QueuedSent+1, r.QueuedErr)
+	}
+	fmt.Printf("Txpool: most pending %d, most queued %d\n", r.MaxStatus.Pending, r.MaxStatus.Queued)
+	switch {
+	case r.FixedGasPrice:
+		fmt.Println("Replacement: fixed gas price, no replacement by price")
+	case r.ReplacementBump < 0 && r.ReplacementErr != nil:
+		fmt.Printf("Replacement: no gas price bump accepted, last error %s\n", r.ReplacementErr)
+	case r.ReplacementBump < 0:
+		fmt.Println("Replacement: no gas price bump tried")
+	default:
+		fmt.Printf("Replacement: accepted with a gas price bump of %d%%\n", r.ReplacementBump)
+	}
+}
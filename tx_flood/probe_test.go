@@ -0,0 +1,110 @@
+package tx_flood
+
+import (
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+
+	"github.com/evrynet-official/evrynet-tools/accounts"
+	"github.com/evrynet-official/evrynet-tools/lib/node"
+)
+
+func TestProbe_Start(t *testing.T) {
+	const (
+		accountSlots = 5
+		accountQueue = 3
+		priceBump    = 10
+	)
+	// a txpool holding accountSlots executable and accountQueue queued txs per account, and nothing is mined.
+	// Like the txpool of the node it only accepts the gas price of the network while fixedPrice is set.
+	var (
+		pending    = make(map[common.Address]map[uint64]*types.Transaction)
+		queued     = make(map[common.Address]map[uint64]*types.Transaction)
+		fixedPrice = true
+	)
+	stub := &txStub{validate: func(tx *types.Transaction) error {
+		if fixedPrice && tx.GasPrice().Cmp(gasPrice) != 0 {
+			return errors.New("Tx gasPrice is different from gasPrice of the network")
+		}
+		from, _ := node.TxSender(tx)
+		if pending[from] == nil {
+			pending[from], queued[from] = make(map[uint64]*types.Transaction), make(map[uint64]*types.Transaction)
+		}
+		if old := queued[from][tx.Nonce()]; old != nil {
+			min := new(big.Int).Div(new(big.Int).Mul(old.GasPrice(), big.NewInt(100+priceBump)), big.NewInt(100))
+			if tx.GasPrice().Cmp(min) < 0 {
+				return errors.New("replacement transaction underpriced")
+			}
+		}
+		switch {
+		case tx.Nonce() == uint64(len(pending[from])):
+			if len(pending[from]) == accountSlots {
+				return errors.New("txpool is full")
+			}
+			pending[from][tx.Nonce()] = tx
+			// promote the queued txs following the new one
+			for n := tx.Nonce() + 1; queued[from][n] != nil; n++ {
+				pending[from][n] = queued[from][n]
+				delete(queued[from], n)
+			}
+		case len(queued[from]) < accountQueue || queued[from][tx.Nonce()] != nil:
+			queued[from][tx.Nonce()] = tx
+		}
+		return nil
+	}, methods: map[string]func() interface{}{
+		"txpool_status": func() interface{} {
+			var status node.TxpoolStatus
+			for from := range pending {
+				status.Pending += hexutil.Uint(len(pending[from]))
+				status.Queued += hexutil.Uint(len(queued[from]))
+			}
+			return status
+		},
+		"txpool_content": func() interface{} {
+			dump := func(txs map[common.Address]map[uint64]*types.Transaction) map[string]map[string]*types.Transaction {
+				content := make(map[string]map[string]*types.Transaction)
+				for from, byNonce := range txs {
+					content[from.Hex()] = make(map[string]*types.Transaction)
+					for nonce, tx := range byNonce {
+						content[from.Hex()][big.NewInt(int64(nonce)).String()] = tx
+					}
+				}
+				return content
+			}
+			return map[string]interface{}{"pending": dump(pending), "queued": dump(queued)}
+		},
+	}}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	pool, err := node.DialPool([]string{server.URL}, node.RoundRobin)
+	assert.NoError(t, err)
+	accs, err := accounts.GenerateAccounts(4, "probe")
+	assert.NoError(t, err)
+
+	probe := &Probe{EvrClient: pool, Accounts: accs, MaxPending: 100, MaxQueued: 6, MaxBump: 50}
+	report, err := probe.Start()
+	assert.NoError(t, err)
+	assert.Equal(t, accountSlots, report.PendingSent)
+	assert.Equal(t, accountSlots, report.PendingHeld)
+	assert.EqualError(t, report.PendingErr, "txpool is full")
+	assert.Equal(t, 6, report.QueuedSent)
+	assert.Equal(t, accountQueue, report.QueuedHeld)
+	assert.Equal(t, 6-accountQueue, report.QueuedDropped)
+	assert.True(t, report.FixedGasPrice)
+	assert.Equal(t, -1, report.ReplacementBump)
+	assert.EqualError(t, report.ReplacementErr, "Tx gasPrice is different from gasPrice of the network")
+
+	// a txpool accepting any gas price replaces a tx at the lowest bump
+	fixedPrice = false
+	report = &ProbeReport{ReplacementBump: -1}
+	assert.NoError(t, probe.probeReplacement(accs[3], report))
+	assert.False(t, report.FixedGasPrice)
+	assert.Equal(t, priceBump, report.ReplacementBump)
+}
@@ -3,6 +3,7 @@ package tx_flood
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
 	"math/big"
 	"math/rand"
 	"reflect"
@@ -16,10 +17,12 @@ import (
 	"github.com/Evrynetlabs/evrynet-node/common"
 	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
 	"github.com/Evrynetlabs/evrynet-node/core/types"
-	"github.com/Evrynetlabs/evrynet-node/evrclient"
 	"github.com/Evrynetlabs/evrynet-node/params"
 
 	"github.com/evrynet-official/evrynet-tools/accounts"
+	"github.com/evrynet-official/evrynet-tools/lib/metrics"
+	"github.com/evrynet-official/evrynet-tools/lib/node"
+	"github.com/evrynet-official/evrynet-tools/lib/results"
 )
 
 type TxFlood struct {
@@ -27,10 +30,103 @@ type TxFlood struct {
 	NumTxPerAcc   int
 	Seed          string
 	FloodMode     FloodMode
-	EvrClient     *evrclient.Client
+	EvrClient     *node.Pool
 	Accounts      []*accounts.Account
 	Continuous    bool
 	SleepInterval time.Duration
+	// ContractTxValue is the amount of wei attached to every setNumber call
+	ContractTxValue *big.Int
+	// ReceiptTimeout is how long to wait for receipts of contract txs after the run
+	ReceiptTimeout time.Duration
+	// Rate limits the number of txs sent per second by all accounts, 0 means unlimited
+	Rate int
+	// ContractAddress is the contract to call instead of deploying a new one
+	ContractAddress *common.Address
+	// Signer signs every tx, an EIP-155 signer of the chain id of the node is used when it is nil
+	Signer types.Signer
+	// Backpressure, when set, adjusts the send rate from the txpool status of the node instead of Rate
+	Backpressure *Backpressure
+	// HotSpot is the fraction of txs sent to HotReceiver (normal txs) or written to one storage slot
+	// (storage txs), the other txs go to random accounts or to the slot of their sender
+	HotSpot float64
+	// HotReceiver receives the hot-spot normal txs, the first account when it is not set
+	HotReceiver *common.Address
+	// TrackInclusion waits for the receipts of normal and storage txs after the run and reports
+	// how long hot-spot and other txs took to be mined
+	TrackInclusion bool
+	// RandSeed seeds the recipients, amounts and kinds of the txs, a seed is taken from the clock when it is 0
+	RandSeed int64
+
+	contract *numberContract
+	mu       *sync.Mutex
+	scTxs    []sentContractTx
+	tracked  []trackedTx
+	hot      *InclusionStats
+	other    *InclusionStats
+	sent     uint64
+	failed   uint64
+	elapsed  time.Duration
+}
+
+// Result summarizes a finished flood
+type Result struct {
+	Sent      uint64               `json:"sent"`
+	Failed    uint64               `json:"failed"`
+	Elapsed   time.Duration        `json:"elapsed"`
+	Endpoints []node.EndpointStats `json:"endpoints"`
+	RandSeed  int64                `json:"rand_seed,omitempty"`
+	// SustainableRate is the rate the txpool kept up with, when the rate was adjusted by backpressure
+	SustainableRate float64 `json:"sustainable_rate,omitempty"`
+	// Hot and Other are the inclusion of hot-spot and other txs, when it was tracked
+	Hot   *InclusionStats `json:"hot,omitempty"`
+	Other *InclusionStats `json:"other,omitempty"`
+}
+
+// TPS returns the rate at which txs were sent
+func (r Result) TPS() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Sent) / r.Elapsed.Seconds()
+}
+
+// Run returns the record of the result to save in a result store
+func (r Result) Run(tool string) *results.Run {
+	var (
+		run      = results.NewRun(tool)
+		requests uint64
+		latency  time.Duration
+	)
+	for _, s := range r.Endpoints {
+		requests += s.Requests
+		latency += s.AvgLatency * time.Duration(s.Requests)
+	}
+	run.Metrics[results.TPS] = r.TPS()
+	run.Metrics["sent"] = float64(r.Sent)
+	run.Metrics["failed"] = float64(r.Failed)
+	run.Metrics["elapsed_s"] = r.Elapsed.Seconds()
+	if requests != 0 {
+		run.Metrics[results.Latency] = float64(latency/time.Duration(requests)) / float64(time.Millisecond)
+	}
+	if r.SustainableRate != 0 {
+		run.Metrics["sustainable_rate"] = r.SustainableRate
+	}
+	if r.Hot != nil && r.Hot.Mined != 0 {
+		run.Metrics["hot_inclusion_ms"] = float64(r.Hot.AvgLatency) / float64(time.Millisecond)
+	}
+	if r.Other != nil && r.Other.Mined != 0 {
+		run.Metrics["other_inclusion_ms"] = float64(r.Other.AvgLatency) / float64(time.Millisecond)
+	}
+	if r.RandSeed != 0 {
+		run.Params["rand-seed"] = fmt.Sprint(r.RandSeed)
+	}
+	return run
+}
+
+// sentContractTx records a setNumber call to verify after the run
+type sentContractTx struct {
+	hash   common.Hash
+	number *big.Int
 }
 
 type FloodMode int
@@ -39,10 +135,16 @@ const (
 	DefaultMode FloodMode = iota
 	NormalTxMode
 	SmartContractMode
+	// StorageMode txs write a storage slot of a contract, the slot of their sender or the hot-spot slot
+	StorageMode
 )
 
 var (
 	gasPrice = big.NewInt(params.GasPriceConfig)
+
+	defaultReceiptTimeout = 30 * time.Second
+	// deployTimeout is the time a deployed contract has to be mined
+	deployTimeout = 10 * time.Second
 )
 
 func handleTxErr(errCh chan error) {
@@ -53,41 +155,109 @@ func handleTxErr(errCh chan error) {
 	}
 }
 
+// Result returns the number of sent and failed txs of the last run
+func (tf *TxFlood) Result() Result {
+	result := Result{
+		Sent:      atomic.LoadUint64(&tf.sent),
+		Failed:    atomic.LoadUint64(&tf.failed),
+		Elapsed:   tf.elapsed,
+		Endpoints: tf.EvrClient.Stats(),
+		RandSeed:  tf.RandSeed,
+	}
+	result.Hot, result.Other = tf.hot, tf.other
+	if tf.Backpressure != nil {
+		result.SustainableRate = tf.Backpressure.SustainableRate()
+	}
+	return result
+}
+
+// prepareRand takes a seed from the clock when none is set and prints it, so that the run can be repeated
+func (tf *TxFlood) prepareRand() {
+	if tf.RandSeed == 0 {
+		tf.RandSeed = time.Now().UnixNano()
+	}
+	fmt.Printf("Random seed: %d\n", tf.RandSeed)
+}
+
+// accountRand returns the random stream of acc, derived from the run seed and the address of acc
+// so that every account draws the same values whatever the goroutine scheduling or the agent it runs on
+func (tf *TxFlood) accountRand(acc *accounts.Account) *rand.Rand {
+	h := fnv.New64a()
+	_, _ = h.Write(acc.Address.Bytes())
+	return rand.New(rand.NewSource(tf.RandSeed ^ int64(h.Sum64())))
+}
+
 func (tf *TxFlood) Start() error {
 	var (
 		errChan      = make(chan error)
-		failed       uint64
 		contractAddr = &common.Address{}
+		tick         <-chan time.Time
 	)
+	tf.sent, tf.failed, tf.elapsed = 0, 0, 0
+	tf.mu, tf.scTxs, tf.tracked, tf.hot, tf.other = &sync.Mutex{}, nil, nil, nil, nil
+	if err := tf.prepareSigner(); err != nil {
+		return err
+	}
+	tf.prepareRand()
 
 	switch tf.FloodMode {
 	case DefaultMode, SmartContractMode:
 		var err error
-		contractAddr, err = tf.prepareNewContract()
+		contractAddr, err = tf.prepareContract()
+		if err != nil {
+			return err
+		}
+	case StorageMode:
+		var err error
+		contractAddr, err = tf.prepareSlotContract()
 		if err != nil {
 			return err
 		}
 	}
 
+	done := make(chan struct{})
+	switch {
+	case tf.Backpressure != nil:
+		paced := make(chan time.Time)
+		tf.Backpressure.start(tf, paced, done)
+		tick = paced
+	case tf.Rate > 0:
+		ticker := time.NewTicker(time.Second / time.Duration(tf.Rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
 	// Start sending tx flood
-	var wg sync.WaitGroup
+	var (
+		wg    sync.WaitGroup
+		start = time.Now()
+	)
 	for _, acc := range tf.Accounts {
 		wg.Add(1)
 		go func(acc *accounts.Account, contractAddr *common.Address) {
 			defer wg.Done()
 			nonce, err := tf.EvrClient.PendingNonceAt(context.Background(), acc.Address)
 			if err != nil {
-				atomic.AddUint64(&failed, uint64(tf.NumTxPerAcc))
+				atomic.AddUint64(&tf.failed, uint64(tf.NumTxPerAcc))
+				metrics.TxsFailed.WithLabelValues(metrics.ErrorClass(err)).Add(float64(tf.NumTxPerAcc))
 				errChan <- err
 				return
 			}
 
-			manualNonce := big.NewInt(int64(nonce))
+			var (
+				manualNonce = big.NewInt(int64(nonce))
+				inFlight    int64
+				rng         = tf.accountRand(acc)
+			)
 			for {
 				for n := 0; n < tf.NumTxPerAcc; n++ {
-					err := tf.sendTx(acc, manualNonce, contractAddr)
+					if tick != nil {
+						<-tick
+					}
+					err := tf.sendTx(acc, manualNonce, contractAddr, rng)
 					if err != nil {
-						atomic.AddUint64(&failed, uint64(1))
+						atomic.AddUint64(&tf.failed, uint64(1))
+						metrics.TxFailed(err)
 						errChan <- err
 
 					}
@@ -95,6 +265,9 @@ func (tf *TxFlood) Start() error {
 				if !tf.Continuous {
 					break
 				}
+				if err := tf.resyncNonce(acc, manualNonce, &inFlight); err != nil {
+					errChan <- err
+				}
 				time.Sleep(tf.SleepInterval)
 			}
 		}(acc, contractAddr)
@@ -103,38 +276,72 @@ func (tf *TxFlood) Start() error {
 	go handleTxErr(errChan)
 
 	wg.Wait()
+	close(done)
 	close(errChan)
+	tf.elapsed = time.Since(start)
+	tf.reportInclusion()
 
-	if failed == 0 {
-		return nil
+	if tf.failed != 0 {
+		return fmt.Errorf("fail to send %d transactions", tf.failed)
 	}
 
-	return fmt.Errorf("fail to send %d transactions", failed)
+	switch tf.FloodMode {
+	case DefaultMode, SmartContractMode:
+		return tf.verifyContractTxs(*contractAddr)
+	}
+	return nil
 }
 
-func (tf *TxFlood) sendTx(acc *accounts.Account, nonce *big.Int, contractAddr *common.Address) error {
-	rand.Seed(time.Now().UnixNano())
+// resyncNonce takes the pending nonce of the node as the nonce of the next tx of acc when it is ahead, and when it
+// is behind, as happens when a tx is dropped, if the txs of acc all go to the endpoint asked: another endpoint may
+// not have seen every tx of acc yet. It updates the number of txs of acc sent but not mined yet.
+func (tf *TxFlood) resyncNonce(acc *accounts.Account, nonce *big.Int, inFlight *int64) error {
+	pending, err := tf.EvrClient.PendingNonceAt(context.Background(), acc.Address)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get nonce of %s", acc.Address.Hex())
+	}
+	if pending > nonce.Uint64() || (pending < nonce.Uint64() && tf.EvrClient.Sticky()) {
+		fmt.Printf("Resync nonce of %s from %d to %d\n", acc.Address.Hex(), nonce.Uint64(), pending)
+		nonce.SetUint64(pending)
+		metrics.NonceResyncs.Inc()
+	}
+	mined, err := tf.EvrClient.NonceAt(context.Background(), acc.Address, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get nonce of %s", acc.Address.Hex())
+	}
+	current := int64(pending) - int64(mined)
+	metrics.TxsInFlight.Add(float64(current - *inFlight))
+	*inFlight = current
+	return nil
+}
+
+func (tf *TxFlood) sendTx(acc *accounts.Account, nonce *big.Int, contractAddr *common.Address, rng *rand.Rand) error {
 	switch tf.FloodMode {
 	case DefaultMode:
-		switch rand.Intn(2) {
+		switch rng.Intn(2) {
 		case 0: // Send Evr
-			err := tf.sendNormalTx(acc, nonce)
+			err := tf.sendNormalTx(acc, nonce, rng)
 			if err != nil {
 				return err
 			}
 		case 1: // Send Evr via SC without provider
-			err := tf.sendSmartContractTx(acc, nonce, contractAddr)
+			err := tf.sendSmartContractTx(acc, nonce, contractAddr, rng)
 			if err != nil {
 				return err
 			}
 		}
 	case NormalTxMode:
-		err := tf.sendNormalTx(acc, nonce)
+		err := tf.sendNormalTx(acc, nonce, rng)
 		if err != nil {
 			return err
 		}
 	case SmartContractMode:
-		err := tf.sendSmartContractTx(acc, nonce, contractAddr)
+		err := tf.sendSmartContractTx(acc, nonce, contractAddr, rng)
+		if err != nil {
+			return err
+		}
+	case StorageMode:
+		err := tf.sendStorageTx(acc, nonce, contractAddr, rng)
 		if err != nil {
 			return err
 		}
@@ -145,55 +352,215 @@ func (tf *TxFlood) sendTx(acc *accounts.Account, nonce *big.Int, contractAddr *c
 	return nil
 }
 
-func (tf *TxFlood) sendNormalTx(acc *accounts.Account, nonce *big.Int) error {
-	randAcc := tf.Accounts[rand.Intn(len(tf.Accounts))]
+func (tf *TxFlood) sendNormalTx(acc *accounts.Account, nonce *big.Int, rng *rand.Rand) error {
+	transaction, hot, err := tf.newNormalTx(acc, nonce.Uint64(), rng)
+	if err != nil {
+		return err
+	}
+
+	err = tf.EvrClient.SendTransaction(context.Background(), transaction)
+	if err != nil {
+		return errors.Wrapf(err, "failed to send %d EVR from %s nonce %s", transaction.Value(), acc.Address.Hex(), nonce.String())
+	}
+	fmt.Printf("Sent %d EVR from %s => %s nonce %s \n", transaction.Value(), acc.Address.Hex(), transaction.To().Hex(), nonce.String())
+	nonce = nonce.Add(nonce, common.Big1)
+	atomic.AddUint64(&tf.sent, 1)
+	metrics.TxsSent.Inc()
+	tf.track(transaction, hot)
+	return nil
+}
+
+// newNormalTx returns a signed tx sending a random amount of EVR from acc to another random account,
+// or to the hot receiver when the tx is picked for the hot spot
+func (tf *TxFlood) newNormalTx(acc *accounts.Account, nonce uint64, rng *rand.Rand) (*types.Transaction, bool, error) {
+	// the seed of runs without hot spot draws the same values as before it existed
+	hot := tf.HotSpot > 0 && rng.Float64() < tf.HotSpot
 	var (
-		estGas uint64 = 30000
-		err    error
+		estGas  uint64 = 30000
+		randAcc        = tf.Accounts[rng.Intn(len(tf.Accounts))]
+		amount         = big.NewInt(rng.Int63n(10) + 1) // Send at least 1 EVR
 	)
-	if !reflect.DeepEqual(acc.Address, randAcc.Address) {
-		amount := big.NewInt(rand.Int63n(10) + 1) // Send at least 1 EVR
-		transaction := types.NewTransaction(nonce.Uint64(), randAcc.Address, amount, estGas, gasPrice, nil)
-		transaction, err = types.SignTx(transaction, types.HomesteadSigner{}, acc.PriKey)
-		if err != nil {
-			return err
+	// pick another account as the recipient when there is one
+	for !hot && len(tf.Accounts) > 1 && reflect.DeepEqual(acc.Address, randAcc.Address) {
+		randAcc = tf.Accounts[rng.Intn(len(tf.Accounts))]
+	}
+	to := randAcc.Address
+	if hot {
+		to = tf.Accounts[0].Address
+		if tf.HotReceiver != nil {
+			to = *tf.HotReceiver
 		}
+	}
+	transaction, err := types.SignTx(types.NewTransaction(nonce, to, amount, estGas, gasPrice, nil), tf.Signer, acc.PriKey)
+	return transaction, hot, err
+}
 
-		err = tf.EvrClient.SendTransaction(context.Background(), transaction)
-		if err != nil {
-			return errors.Wrapf(err, "failed to send %d EVR from %s nonce %s", amount, acc.Address.Hex(), nonce.String())
-		}
-		fmt.Printf("Sent %d EVR from %s => %s nonce %s \n", amount, acc.Address.Hex(), randAcc.Address.Hex(), nonce.String())
-		nonce = nonce.Add(nonce, common.Big1)
+func (tf *TxFlood) sendSmartContractTx(acc *accounts.Account, nonce *big.Int, contractAddr *common.Address, rng *rand.Rand) error {
+	tx, number, err := tf.newSmartContractTx(acc, nonce.Uint64(), *contractAddr, rng)
+	if err != nil {
+		return err
+	}
+
+	err = tf.EvrClient.SendTransaction(context.Background(), tx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to send Tx to SC %s from %s nonce %s", contractAddr.Hex(), acc.Address.Hex(), nonce.String())
+	}
+	nonce = nonce.Add(nonce, common.Big1)
+	atomic.AddUint64(&tf.sent, 1)
+	metrics.TxsSent.Inc()
+	fmt.Printf("Sent setNumber(%s) from %s => SC %s\n", number.String(), acc.Address.Hex(), contractAddr.Hex())
 
+	if !tf.Continuous {
+		tf.mu.Lock()
+		tf.scTxs = append(tf.scTxs, sentContractTx{hash: tx.Hash(), number: number})
+		tf.mu.Unlock()
 	}
 	return nil
 }
 
-func (tf *TxFlood) sendSmartContractTx(acc *accounts.Account, nonce *big.Int, contractAddr *common.Address) error {
-	randAcc := tf.Accounts[rand.Intn(len(tf.Accounts))]
-	if !reflect.DeepEqual(acc.Address, randAcc.Address) {
+// newSmartContractTx returns a signed tx calling setNumber of the contract with a random number
+func (tf *TxFlood) newSmartContractTx(acc *accounts.Account, nonce uint64, contractAddr common.Address, rng *rand.Rand) (*types.Transaction, *big.Int, error) {
+	var (
+		estGas uint64 = 40000
+		number        = big.NewInt(rng.Int63n(1000) + 1)
+		value         = tf.ContractTxValue
+	)
+	if value == nil {
+		value = common.Big0
+	}
+	// data to call setNumber(number) of this contract
+	data, err := tf.contract.setNumberData(number)
+	if err != nil {
+		return nil, nil, err
+	}
+	tx := types.NewTransaction(nonce, contractAddr, value, estGas, gasPrice, data)
+	tx, err = types.SignTx(tx, tf.Signer, acc.PriKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tx, number, nil
+}
 
-		var (
-			estGas uint64 = 40000
-			err    error
-		)
-		// data to interact with a function of this contract
-		dataBytes := []byte("0x3fb5c1cb0000000000000000000000000000000000000000000000000000000000000002")
-		tx := types.NewTransaction(nonce.Uint64(), *contractAddr, big.NewInt(0), estGas, gasPrice, dataBytes)
-		tx, err = types.SignTx(tx, types.HomesteadSigner{}, acc.PriKey)
+// verifyContractTxs waits for the receipts of every sent setNumber call, counts how many executed
+// successfully or reverted and reads the stored number back from the contract.
+func (tf *TxFlood) verifyContractTxs(contractAddr common.Address) error {
+	var (
+		success, reverted, missing int
+		numbers                    = make(map[string]bool)
+		timeout                    = tf.ReceiptTimeout
+	)
+	if len(tf.scTxs) == 0 {
+		return nil
+	}
+	if timeout == 0 {
+		timeout = defaultReceiptTimeout
+	}
+	fmt.Printf("--- Verifying %d transactions sent to SC %s ...\n", len(tf.scTxs), contractAddr.Hex())
+	deadline := time.Now().Add(timeout)
+	for _, sent := range tf.scTxs {
+		receipt, err := tf.waitForReceipt(sent.hash, deadline)
+		switch {
+		case err != nil:
+			missing++
+			fmt.Printf("failed to get receipt of tx %s, error %s\n", sent.hash.Hex(), err)
+		case receipt.Status == types.ReceiptStatusSuccessful:
+			success++
+			numbers[sent.number.String()] = true
+		default:
+			reverted++
+			fmt.Printf("tx %s was reverted\n", sent.hash.Hex())
+		}
+	}
 
-		err = tf.EvrClient.SendTransaction(context.Background(), tx)
-		if err != nil {
-			return errors.Wrapf(err, "failed to send Tx to SC %s from %s nonce %s", contractAddr.Hex(), acc.Address.Hex(), nonce.String())
+	number, err := tf.contract.getNumber(tf.EvrClient, contractAddr)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read number from SC %s", contractAddr.Hex())
+	}
+	fmt.Println("-----------Smart Contract Stats----------------")
+	fmt.Println("Successful Txs:", success)
+	fmt.Println("Reverted Txs:", reverted)
+	fmt.Println("Txs without receipt:", missing)
+	fmt.Println("Number stored in SC:", number.String())
+
+	if reverted != 0 || missing != 0 {
+		return fmt.Errorf("%d transactions to SC were reverted, %d have no receipt", reverted, missing)
+	}
+	if !numbers[number.String()] {
+		return fmt.Errorf("number stored in SC %s was not set by any successful transaction", number.String())
+	}
+	return nil
+}
+
+// waitForReceipt returns the receipt of a tx, checking for it at every new block until deadline
+func (tf *TxFlood) waitForReceipt(hash common.Hash, deadline time.Time) (*types.Receipt, error) {
+	heads := make(chan *types.Header)
+	sub := tf.EvrClient.SubscribeNewHeads(node.DefaultPollInterval, heads)
+	defer sub.Unsubscribe()
+	timeout := time.NewTimer(time.Until(deadline))
+	defer timeout.Stop()
+	for {
+		receipt, err := tf.EvrClient.TransactionReceipt(context.Background(), hash)
+		switch {
+		case err == nil:
+			return receipt, nil
+		case err != evrynet.NotFound:
+			return nil, err
+		}
+		select {
+		case <-heads:
+		case <-timeout.C:
+			return nil, errors.New("timed out waiting for receipt")
 		}
-		nonce = nonce.Add(nonce, common.Big1)
-		fmt.Printf("Sent Tx from %s => SC %s\n", acc.Address.Hex(), contractAddr.Hex())
 	}
+}
+
+// prepareSigner reads the chain id from the node when no signer is set
+func (tf *TxFlood) prepareSigner() error {
+	if tf.Signer != nil {
+		return nil
+	}
+	signer, err := node.NewSigner(tf.EvrClient, nil, false)
+	if err != nil {
+		return errors.Wrap(err, "failed to get chain id")
+	}
+	tf.Signer = signer
 	return nil
 }
 
-func (tf *TxFlood) prepareNewContract() (*common.Address, error) {
+// prepareContract returns the contract to send setNumber calls to, deploying a new one unless ContractAddress is set
+func (tf *TxFlood) prepareContract() (*common.Address, error) {
+	var err error
+	if tf.contract, err = newNumberContract(); err != nil {
+		return nil, err
+	}
+	if tf.ContractAddress != nil {
+		return tf.ContractAddress, nil
+	}
+	return tf.deployContract(numberContractBin)
+}
+
+// prepareSlotContract returns the contract written by StorageMode txs, deploying a new one unless ContractAddress is set
+func (tf *TxFlood) prepareSlotContract() (*common.Address, error) {
+	if tf.ContractAddress != nil {
+		return tf.ContractAddress, nil
+	}
+	return tf.deployContract(slotContractBin)
+}
+
+// DeployNumberContract deploys the contract called by SmartContractMode from the first account
+func (tf *TxFlood) DeployNumberContract() (common.Address, error) {
+	if err := tf.prepareSigner(); err != nil {
+		return common.Address{}, err
+	}
+	addr, err := tf.deployContract(numberContractBin)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return *addr, nil
+}
+
+// deployContract deploys the contract of bytecode bin from the first account
+func (tf *TxFlood) deployContract(bin string) (*common.Address, error) {
 	acc := tf.Accounts[0]
 	nonce, err := tf.EvrClient.PendingNonceAt(context.Background(), acc.Address)
 	if err != nil {
@@ -201,8 +568,7 @@ func (tf *TxFlood) prepareNewContract() (*common.Address, error) {
 	}
 
 	// payload to create a smart contract
-	payload := "0x608060405260d0806100126000396000f30060806040526004361060525763ffffffff7c01000000000000000000000000000000000000000000000000000000006000350416633fb5c1cb811460545780638381f58a14605d578063f2c9ecd8146081575b005b60526004356093565b348015606857600080fd5b50606f6098565b60408051918252519081900360200190f35b348015608c57600080fd5b50606f609e565b600055565b60005481565b600054905600a165627a7a723058209573e4f95d10c1e123e905d720655593ca5220830db660f0641f3175c1cdb86e0029"
-	payLoadBytes, err := hexutil.Decode(payload)
+	payLoadBytes, err := hexutil.Decode(bin)
 	if err != nil {
 		return nil, err
 	}
@@ -218,7 +584,10 @@ func (tf *TxFlood) prepareNewContract() (*common.Address, error) {
 		return nil, err
 	}
 	tx := types.NewContractCreation(nonce, big.NewInt(0), estGas, gasPrice, payLoadBytes)
-	tx, err = types.SignTx(tx, types.HomesteadSigner{}, acc.PriKey)
+	tx, err = types.SignTx(tx, tf.Signer, acc.PriKey)
+	if err != nil {
+		return nil, err
+	}
 
 	err = tf.EvrClient.SendTransaction(context.Background(), tx)
 	if err != nil {
@@ -226,13 +595,9 @@ func (tf *TxFlood) prepareNewContract() (*common.Address, error) {
 	}
 
 	// Wait to get SC address
-	for i := 0; i < 10; i++ {
-		var receipt *types.Receipt
-		receipt, err = tf.EvrClient.TransactionReceipt(context.Background(), tx.Hash())
-		if err == nil && receipt.Status == uint64(1) {
-			return &receipt.ContractAddress, nil
-		}
-		time.Sleep(1 * time.Second)
+	receipt, err := tf.waitForReceipt(tx.Hash(), time.Now().Add(deployTimeout))
+	if err != nil || receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, errors.New("Can not get SC address")
 	}
-	return nil, errors.New("Can not get SC address")
+	return &receipt.ContractAddress, nil
 }
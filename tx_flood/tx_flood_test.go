@@ -1,12 +1,15 @@
 package tx_flood
 
 import (
+	"math/big"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
-	"github.com/Evrynetlabs/evrynet-node/evrclient"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
 	"github.com/evrynet-official/evrynet-tools/accounts"
+	"github.com/evrynet-official/evrynet-tools/lib/node"
 )
 
 // Notice: you must run this script to deposit test accounts
@@ -40,7 +43,7 @@ func TestTxFlood_floodNormalMode(t *testing.T) {
 			var err error
 			tt.txFlood.Accounts, err = accounts.GenerateAccounts(tt.txFlood.NumAcc, tt.txFlood.Seed)
 			assert.NoError(t, err)
-			tt.txFlood.EvrClient, err = evrclient.Dial("http://0.0.0.0:22001")
+			tt.txFlood.EvrClient, err = node.DialPool([]string{"http://0.0.0.0:22001"}, node.RoundRobin)
 			assert.NoError(t, err)
 			assert.NoError(t, tt.txFlood.Start())
 		})
@@ -78,7 +81,7 @@ func TestTxFlood_floodSmartContractMode(t *testing.T) {
 			var err error
 			tt.txFlood.Accounts, err = accounts.GenerateAccounts(tt.txFlood.NumAcc, tt.txFlood.Seed)
 			assert.NoError(t, err)
-			tt.txFlood.EvrClient, err = evrclient.Dial("http://0.0.0.0:22001")
+			tt.txFlood.EvrClient, err = node.DialPool([]string{"http://0.0.0.0:22001"}, node.RoundRobin)
 			assert.NoError(t, err)
 			assert.NoError(t, tt.txFlood.Start())
 		})
@@ -116,9 +119,47 @@ func TestTxFlood_floodDefaultMode(t *testing.T) {
 			var err error
 			tt.txFlood.Accounts, err = accounts.GenerateAccounts(tt.txFlood.NumAcc, tt.txFlood.Seed)
 			assert.NoError(t, err)
-			tt.txFlood.EvrClient, err = evrclient.Dial("http://0.0.0.0:22001")
+			tt.txFlood.EvrClient, err = node.DialPool([]string{"http://0.0.0.0:22001"}, node.RoundRobin)
 			assert.NoError(t, err)
 			assert.NoError(t, tt.txFlood.Start())
 		})
 	}
 }
+
+func TestNumberContract_setNumberData(t *testing.T) {
+	contract, err := newNumberContract()
+	assert.NoError(t, err)
+	data, err := contract.setNumberData(big.NewInt(2))
+	assert.NoError(t, err)
+	assert.Equal(t, "0x3fb5c1cb0000000000000000000000000000000000000000000000000000000000000002", hexutil.Encode(data))
+}
+
+func TestTxFlood_resyncNonce(t *testing.T) {
+	stub := &txStub{nonce: 5}
+	s1, s2 := httptest.NewServer(stub), httptest.NewServer(stub)
+	defer s1.Close()
+	defer s2.Close()
+	accs, err := accounts.GenerateAccounts(1, "resync")
+	assert.NoError(t, err)
+
+	for _, tt := range []struct {
+		policy node.Policy
+		local  uint64
+		want   uint64
+	}{
+		{node.RoundRobin, 3, 5},
+		// an endpoint behind the others does not rewind the nonce
+		{node.RoundRobin, 8, 8},
+		{node.Sticky, 8, 5},
+	} {
+		pool, err := node.DialPool([]string{s1.URL, s2.URL}, tt.policy)
+		assert.NoError(t, err)
+		var (
+			tf       = &TxFlood{EvrClient: pool}
+			nonce    = new(big.Int).SetUint64(tt.local)
+			inFlight int64
+		)
+		assert.NoError(t, tf.resyncNonce(accs[0], nonce, &inFlight))
+		assert.Equal(t, tt.want, nonce.Uint64(), "%s from %d", tt.policy, tt.local)
+	}
+}
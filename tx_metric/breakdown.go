@@ -0,0 +1,239 @@
+package tx_metric
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/accounts/abi"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	stakingContracts "github.com/Evrynetlabs/evrynet-node/consensus/staking_contracts"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+
+	"github.com/evrynet-official/evrynet-tools/lib/node"
+)
+
+const (
+	KindTransfer = "transfer"
+	KindCreate   = "create"
+	KindCall     = "call"
+)
+
+// knownSignatures are methods decoded without an ABI: setNumber is called by the SC mode of tx_flood
+var knownSignatures = map[string]string{
+	"tx_flood": "setNumber(uint256)",
+	"erc20":    "transfer(address,uint256)",
+}
+
+// TxClass is a group of txs of the same kind, to the same contract and method for calls
+type TxClass struct {
+	Kind     string
+	Contract *common.Address
+	// Selector is the first 4 bytes of the data of a call, empty when the data is shorter
+	Selector string
+	// Method is the name of the method of Selector in a known ABI, empty when it is unknown
+	Method  string
+	Txs     int64
+	Failed  int64
+	GasUsed uint64
+}
+
+func (c *TxClass) String() string {
+	switch {
+	case c.Kind != KindCall:
+		return c.Kind
+	case c.Method != "":
+		return fmt.Sprintf("%s %s %s", c.Kind, c.Contract.Hex(), c.Method)
+	case c.Selector != "":
+		return fmt.Sprintf("%s %s %s", c.Kind, c.Contract.Hex(), c.Selector)
+	}
+	return fmt.Sprintf("%s %s fallback", c.Kind, c.Contract.Hex())
+}
+
+// SenderStats are the txs sent by an account
+type SenderStats struct {
+	Address common.Address
+	Txs     int64
+	Failed  int64
+	GasUsed uint64
+}
+
+// Breakdown classifies txs as transfers, contract creations and calls grouped by contract and method,
+// and counts the txs, failures and gas used of every class and sender from the receipts
+type Breakdown struct {
+	Classes []*TxClass
+	Senders []*SenderStats
+	Txs     int64
+	GasUsed uint64
+
+	mu      *sync.Mutex
+	methods map[string]string
+	classes map[string]*TxClass
+	senders map[common.Address]*SenderStats
+}
+
+// NewBreakdown returns a breakdown decoding the methods of the staking contract, of knownSignatures and of abis
+func NewBreakdown(abis ...abi.ABI) (*Breakdown, error) {
+	b := &Breakdown{
+		mu:      &sync.Mutex{},
+		methods: make(map[string]string),
+		classes: make(map[string]*TxClass),
+		senders: make(map[common.Address]*SenderStats),
+	}
+	for _, signature := range knownSignatures {
+		b.methods[hexutil.Encode(crypto.Keccak256([]byte(signature))[:4])] = signature
+	}
+	staking, err := abi.JSON(strings.NewReader(stakingContracts.StakingContractsABI))
+	if err != nil {
+		return nil, err
+	}
+	for _, known := range append([]abi.ABI{staking}, abis...) {
+		for _, method := range known.Methods {
+			b.methods[hexutil.Encode(method.Id())] = method.Sig()
+		}
+	}
+	return b, nil
+}
+
+// LoadABIs reads the ABIs of JSON files
+func LoadABIs(paths []string) ([]abi.ABI, error) {
+	var abis []abi.ABI
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := abi.JSON(file)
+		file.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid ABI %s", filepath.Base(path))
+		}
+		abis = append(abis, parsed)
+	}
+	return abis, nil
+}
+
+// Add counts a tx with its receipt
+func (b *Breakdown) Add(tx *types.Transaction, receipt *types.Receipt) error {
+	sender, err := node.TxSender(tx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to recover sender of tx %s", tx.Hash().Hex())
+	}
+	class := TxClass{Kind: KindTransfer, Contract: tx.To()}
+	switch data := tx.Data(); {
+	case tx.To() == nil:
+		class.Kind = KindCreate
+	case len(data) != 0:
+		class.Kind = KindCall
+		if len(data) >= 4 {
+			class.Selector = hexutil.Encode(data[:4])
+			class.Method = b.methods[class.Selector]
+		}
+	}
+	key := class.Kind
+	if class.Kind == KindCall {
+		key = fmt.Sprintf("%s/%s/%s", class.Kind, class.Contract.Hex(), class.Selector)
+	}
+	failed := receipt.Status != types.ReceiptStatusSuccessful
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.Txs++
+	b.GasUsed += receipt.GasUsed
+	c, ok := b.classes[key]
+	if !ok {
+		c = &class
+		if c.Kind != KindCall {
+			c.Contract = nil
+		}
+		b.classes[key] = c
+		b.Classes = append(b.Classes, c)
+	}
+	s, ok := b.senders[sender]
+	if !ok {
+		s = &SenderStats{Address: sender}
+		b.senders[sender] = s
+		b.Senders = append(b.Senders, s)
+	}
+	c.Txs++
+	c.GasUsed += receipt.GasUsed
+	s.Txs++
+	s.GasUsed += receipt.GasUsed
+	if failed {
+		c.Failed++
+		s.Failed++
+	}
+	return nil
+}
+
+// AddBlock counts the txs of a block with their receipts, in the order of the txs
+func (b *Breakdown) AddBlock(bl *types.Block, receipts []*types.Receipt) error {
+	if len(receipts) != bl.Transactions().Len() {
+		return fmt.Errorf("block %d has %d txs but %d receipts", bl.NumberU64(), bl.Transactions().Len(), len(receipts))
+	}
+	for i, tx := range bl.Transactions() {
+		if err := b.Add(tx, receipts[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Print prints the top classes and senders by number of txs on console view, all of them when top is 0
+func (b *Breakdown) Print(top int) {
+	if top <= 0 {
+		top = len(b.Classes) + len(b.Senders)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	sort.SliceStable(b.Classes, func(i, j int) bool { return b.Classes[i].Txs > b.Classes[j].Txs })
+	sort.SliceStable(b.Senders, func(i, j int) bool { return b.Senders[i].Txs > b.Senders[j].Txs })
+
+	fmt.Println("-----------Breakdown Stats----------------")
+	fmt.Println("Total Tx:", b.Txs)
+	fmt.Println("Total Gas used:", b.GasUsed)
+	fmt.Printf("%-90s %8s %8s %9s\n", "Class", "Txs", "Gas %", "Failed %")
+	for i, c := range b.Classes {
+		if i == top {
+			fmt.Printf("... %d other classes\n", len(b.Classes)-top)
+			break
+		}
+		fmt.Printf("%-90s %8d %7.2f%% %8.2f%%\n", c, c.Txs, b.share(c.GasUsed), ratio(c.Failed, c.Txs))
+	}
+	fmt.Printf("Top %d senders of %d:\n", min(top, len(b.Senders)), len(b.Senders))
+	for i, s := range b.Senders {
+		if i == top {
+			break
+		}
+		fmt.Printf("%-90s %8d %7.2f%% %8.2f%%\n", s.Address.Hex(), s.Txs, b.share(s.GasUsed), ratio(s.Failed, s.Txs))
+	}
+}
+
+// share returns the percentage of the gas used by every tx that gasUsed is
+func (b *Breakdown) share(gasUsed uint64) float64 {
+	if b.GasUsed == 0 {
+		return 0
+	}
+	return float64(gasUsed) / float64(b.GasUsed) * 100
+}
+
+func ratio(n, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total) * 100
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,67 @@
+package tx_metric
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/accounts/abi"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	stakingContracts "github.com/Evrynetlabs/evrynet-node/consensus/staking_contracts"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+)
+
+func TestBreakdown_Add(t *testing.T) {
+	var (
+		flooder, _  = crypto.GenerateKey()
+		voter, _    = crypto.GenerateKey()
+		receiver    = common.HexToAddress("0x45F8B547A7f16730c0C8961A21b56c31d84DdB49")
+		stakingSc   = common.HexToAddress("0x0000000000000000000000000000000000000011")
+		staking, _  = abi.JSON(strings.NewReader(stakingContracts.StakingContractsABI))
+		voteData, _ = staking.Pack("vote", receiver)
+		nonce       uint64
+	)
+	b, err := NewBreakdown()
+	require.NoError(t, err)
+	sign := func(tx *types.Transaction, status, gasUsed uint64, voting bool) {
+		key := flooder
+		if voting {
+			key = voter
+		}
+		signed, err := types.SignTx(tx, types.HomesteadSigner{}, key)
+		require.NoError(t, err)
+		require.NoError(t, b.Add(signed, &types.Receipt{Status: status, GasUsed: gasUsed}))
+		nonce++
+	}
+	for i := 0; i < 3; i++ {
+		sign(types.NewTransaction(nonce, receiver, big.NewInt(1), 21000, big.NewInt(1), nil), types.ReceiptStatusSuccessful, 21000, false)
+	}
+	sign(types.NewContractCreation(nonce, common.Big0, 100000, big.NewInt(1), []byte{0x60}), types.ReceiptStatusSuccessful, 53000, false)
+	sign(types.NewTransaction(nonce, stakingSc, common.Big0, 100000, big.NewInt(1), voteData), types.ReceiptStatusSuccessful, 60000, true)
+	sign(types.NewTransaction(nonce, stakingSc, common.Big0, 100000, big.NewInt(1), voteData), types.ReceiptStatusFailed, 30000, true)
+	sign(types.NewTransaction(nonce, stakingSc, common.Big0, 100000, big.NewInt(1), []byte{0x01}), types.ReceiptStatusSuccessful, 23000, true)
+
+	assert.Equal(t, int64(7), b.Txs)
+	assert.Equal(t, uint64(229000), b.GasUsed)
+	require.Len(t, b.Classes, 4)
+	assert.Equal(t, "transfer", b.Classes[0].String())
+	assert.Equal(t, int64(3), b.Classes[0].Txs)
+	assert.Equal(t, "create", b.Classes[1].String())
+
+	vote := b.Classes[2]
+	assert.Equal(t, "call "+stakingSc.Hex()+" vote(address)", vote.String())
+	assert.Equal(t, int64(2), vote.Txs)
+	assert.Equal(t, int64(1), vote.Failed)
+	assert.Equal(t, uint64(90000), vote.GasUsed)
+	assert.Equal(t, "call "+stakingSc.Hex()+" fallback", b.Classes[3].String())
+
+	require.Len(t, b.Senders, 2)
+	assert.Equal(t, crypto.PubkeyToAddress(flooder.PublicKey), b.Senders[0].Address)
+	assert.Equal(t, int64(4), b.Senders[0].Txs)
+	assert.Equal(t, int64(1), b.Senders[1].Failed)
+	b.Print(2)
+}
@@ -0,0 +1,259 @@
+package tx_metric
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/rlp"
+)
+
+var (
+	blockPrefix    = []byte("b")
+	receiptPrefix  = []byte("r")
+	proposerPrefix = []byte("p")
+	genesisKey     = []byte("genesis")
+)
+
+// ErrNotCached is returned in offline mode for a block, receipt or proposer that is not in the cache
+var ErrNotCached = errors.New("not cached")
+
+// Cache stores blocks, receipts and proposers on disk, so that a range is analysed again without the node.
+// Blocks are stored as RLP by number and receipts as JSON by tx hash.
+type Cache struct {
+	db *leveldb.DB
+}
+
+// OpenCache opens the cache in the directory path, creating it when it does not exist
+func OpenCache(path string) (*Cache, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can not open cache %s", path)
+	}
+	return &Cache{db: db}, nil
+}
+
+// Close closes the cache
+func (c *Cache) Close() error {
+	return c.db.Close()
+}
+
+func hashKey(prefix []byte, hash common.Hash) []byte {
+	return append(append([]byte{}, prefix...), hash.Bytes()...)
+}
+
+func numberKey(prefix []byte, number uint64) []byte {
+	key := make([]byte, len(prefix)+8)
+	copy(key, prefix)
+	binary.BigEndian.PutUint64(key[len(prefix):], number)
+	return key
+}
+
+// get returns the value of key, nil when it is not stored
+func (c *Cache) get(key []byte) ([]byte, error) {
+	value, err := c.db.Get(key, nil)
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	}
+	return value, err
+}
+
+// Block returns a cached block, nil when it is not cached
+func (c *Cache) Block(number uint64) (*types.Block, error) {
+	data, err := c.get(numberKey(blockPrefix, number))
+	if err != nil || data == nil {
+		return nil, err
+	}
+	bl := new(types.Block)
+	if err := rlp.DecodeBytes(data, bl); err != nil {
+		return nil, errors.Wrapf(err, "invalid cached block %d", number)
+	}
+	return bl, nil
+}
+
+// PutBlock caches a block
+func (c *Cache) PutBlock(bl *types.Block) error {
+	data, err := rlp.EncodeToBytes(bl)
+	if err != nil {
+		return err
+	}
+	return c.db.Put(numberKey(blockPrefix, bl.NumberU64()), data, nil)
+}
+
+// Receipt returns the cached receipt of a tx, nil when it is not cached
+func (c *Cache) Receipt(hash common.Hash) (*types.Receipt, error) {
+	data, err := c.get(hashKey(receiptPrefix, hash))
+	if err != nil || data == nil {
+		return nil, err
+	}
+	receipt := new(types.Receipt)
+	if err := json.Unmarshal(data, receipt); err != nil {
+		return nil, errors.Wrapf(err, "invalid cached receipt %s", hash.Hex())
+	}
+	return receipt, nil
+}
+
+// PutReceipt caches the receipt of a tx
+func (c *Cache) PutReceipt(receipt *types.Receipt) error {
+	if receipt.Logs == nil {
+		// a receipt without logs array is rejected when it is read
+		receipt.Logs = []*types.Log{}
+	}
+	data, err := json.Marshal(receipt)
+	if err != nil {
+		return err
+	}
+	return c.db.Put(hashKey(receiptPrefix, receipt.TxHash), data, nil)
+}
+
+// Proposer returns the cached proposer of a block, nil when it is not cached
+func (c *Cache) Proposer(number uint64) (*common.Address, error) {
+	data, err := c.get(numberKey(proposerPrefix, number))
+	if err != nil || data == nil {
+		return nil, err
+	}
+	proposer := common.BytesToAddress(data)
+	return &proposer, nil
+}
+
+// PutProposer caches the proposer of a block
+func (c *Cache) PutProposer(number uint64, proposer common.Address) error {
+	return c.db.Put(numberKey(proposerPrefix, number), proposer.Bytes(), nil)
+}
+
+// CheckGenesis records the hash of the genesis block of the first node the cache is filled from,
+// and returns an error when genesis is the genesis block of another chain
+func (c *Cache) CheckGenesis(genesis common.Hash) error {
+	data, err := c.get(genesisKey)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return c.db.Put(genesisKey, genesis.Bytes(), nil)
+	}
+	if cached := common.BytesToHash(data); cached != genesis {
+		return fmt.Errorf("the cache holds blocks of chain %s, not of the node whose genesis is %s", cached.Hex(), genesis.Hex())
+	}
+	return nil
+}
+
+// First returns the number of the earliest cached block
+func (c *Cache) First() (uint64, error) {
+	iter := c.db.NewIterator(util.BytesPrefix(blockPrefix), nil)
+	defer iter.Release()
+	if !iter.First() {
+		if err := iter.Error(); err != nil {
+			return 0, err
+		}
+		return 0, errors.Wrap(ErrNotCached, "no block")
+	}
+	return binary.BigEndian.Uint64(iter.Key()[len(blockPrefix):]), nil
+}
+
+// Last returns the number of the latest cached block
+func (c *Cache) Last() (uint64, error) {
+	iter := c.db.NewIterator(util.BytesPrefix(blockPrefix), nil)
+	defer iter.Release()
+	if !iter.Last() {
+		if err := iter.Error(); err != nil {
+			return 0, err
+		}
+		return 0, errors.Wrap(ErrNotCached, "no block")
+	}
+	return binary.BigEndian.Uint64(iter.Key()[len(blockPrefix):]), nil
+}
+
+// Close closes the cache, if it is set
+func (tm *TxMetric) Close() error {
+	if tm.Cache == nil {
+		return nil
+	}
+	return tm.Cache.Close()
+}
+
+// block returns a block from the cache, or from the node when it is not cached and caches it
+func (tm *TxMetric) block(number uint64) (*types.Block, error) {
+	if tm.Cache == nil {
+		return FetchBlock(tm.EvrClient, number)
+	}
+	bl, err := tm.Cache.Block(number)
+	if err != nil || bl != nil {
+		return bl, err
+	}
+	if tm.Offline {
+		return nil, errors.Wrapf(ErrNotCached, "block %d", number)
+	}
+	if bl, err = FetchBlock(tm.EvrClient, number); err != nil {
+		return nil, err
+	}
+	return bl, tm.Cache.PutBlock(bl)
+}
+
+// receipts returns the receipts of the txs of a block from the cache, or from the node when they are not cached
+func (tm *TxMetric) receipts(bl *types.Block) ([]*types.Receipt, error) {
+	var (
+		txs      = bl.Transactions()
+		receipts = make([]*types.Receipt, len(txs))
+		missing  []common.Hash
+		err      error
+	)
+	for i, tx := range txs {
+		if tm.Cache != nil {
+			if receipts[i], err = tm.Cache.Receipt(tx.Hash()); err != nil {
+				return nil, err
+			}
+		}
+		if receipts[i] == nil {
+			if tm.Offline {
+				return nil, errors.Wrapf(ErrNotCached, "receipt of tx %s", tx.Hash().Hex())
+			}
+			missing = append(missing, tx.Hash())
+		}
+	}
+	if len(missing) == 0 {
+		return receipts, nil
+	}
+	fetched, err := tm.fetchReceipts(missing)
+	if err != nil {
+		return nil, err
+	}
+	for i := range receipts {
+		if receipts[i] != nil {
+			continue
+		}
+		receipts[i], fetched = fetched[0], fetched[1:]
+		if tm.Cache != nil {
+			if err := tm.Cache.PutReceipt(receipts[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return receipts, nil
+}
+
+// Fill caches the blocks from first to last, with the receipts of their txs when receipts is set
+func (tm *TxMetric) Fill(first, last uint64, receipts bool) error {
+	if tm.Cache == nil {
+		return errors.New("no cache to fill")
+	}
+	if last < first {
+		return fmt.Errorf("invalid block range %d - %d", first, last)
+	}
+	for from := first; from <= last; from += tm.chunkSize() {
+		to := from + tm.chunkSize() - 1
+		if to > last {
+			to = last
+		}
+		if _, err := tm.fetchRange(from, to, receipts); err != nil {
+			return err
+		}
+		fmt.Printf("Cached blocks %d to %d\n", from, to)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package tx_metric
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/crypto"
+)
+
+func newTestCache(t *testing.T) (*Cache, func()) {
+	dir, err := ioutil.TempDir("", "cache")
+	require.NoError(t, err)
+	cache, err := OpenCache(dir)
+	require.NoError(t, err)
+	return cache, func() {
+		cache.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+// testBlocks returns blocks from 1 to n, one every 2s, block i holds i-1 txs
+func testBlocks(t *testing.T, n int) ([]*types.Block, []*types.Receipt) {
+	var (
+		key, _   = crypto.GenerateKey()
+		to       = common.HexToAddress("0x45F8B547A7f16730c0C8961A21b56c31d84DdB49")
+		nonce    uint64
+		blocks   []*types.Block
+		receipts []*types.Receipt
+	)
+	for i := 1; i <= n; i++ {
+		var txs []*types.Transaction
+		for j := 0; j < i-1; j++ {
+			tx, err := types.SignTx(types.NewTransaction(nonce, to, big.NewInt(1), 21000, big.NewInt(1), nil), types.HomesteadSigner{}, key)
+			require.NoError(t, err)
+			txs = append(txs, tx)
+			receipts = append(receipts, &types.Receipt{Status: types.ReceiptStatusSuccessful, GasUsed: 21000, CumulativeGasUsed: 21000, TxHash: tx.Hash()})
+			nonce++
+		}
+		header := &types.Header{Number: big.NewInt(int64(i)), Time: uint64(100 + 2*i), GasLimit: 1000000, GasUsed: uint64(21000 * len(txs)), Difficulty: common.Big1}
+		blocks = append(blocks, types.NewBlock(header, txs, nil, nil))
+	}
+	return blocks, receipts
+}
+
+func TestCache(t *testing.T) {
+	cache, done := newTestCache(t)
+	defer done()
+	blocks, receipts := testBlocks(t, 3)
+
+	bl, err := cache.Block(3)
+	require.NoError(t, err)
+	assert.Nil(t, bl)
+	for _, bl := range blocks {
+		require.NoError(t, cache.PutBlock(bl))
+	}
+	bl, err = cache.Block(3)
+	require.NoError(t, err)
+	assert.Equal(t, blocks[2].Hash(), bl.Hash())
+	assert.Equal(t, 2, bl.Transactions().Len())
+	assert.Equal(t, blocks[2].Transactions()[1].Hash(), bl.Transactions()[1].Hash())
+
+	require.NoError(t, cache.PutReceipt(receipts[0]))
+	receipt, err := cache.Receipt(receipts[0].TxHash)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(21000), receipt.GasUsed)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+
+	proposer := common.HexToAddress("0x45F8B547A7f16730c0C8961A21b56c31d84DdB49")
+	require.NoError(t, cache.PutProposer(2, proposer))
+	cached, err := cache.Proposer(2)
+	require.NoError(t, err)
+	assert.Equal(t, proposer, *cached)
+
+	require.NoError(t, cache.CheckGenesis(common.HexToHash("0x01")))
+	require.NoError(t, cache.CheckGenesis(common.HexToHash("0x01")))
+	assert.Error(t, cache.CheckGenesis(common.HexToHash("0x02")))
+}
+
+func TestTxMetric_MetricByBlockOffline(t *testing.T) {
+	cache, done := newTestCache(t)
+	defer done()
+	blocks, receipts := testBlocks(t, 6)
+	for _, bl := range blocks {
+		require.NoError(t, cache.PutBlock(bl))
+	}
+	for _, receipt := range receipts {
+		require.NoError(t, cache.PutReceipt(receipt))
+	}
+
+	breakdown, err := NewBreakdown()
+	require.NoError(t, err)
+	tm := &TxMetric{
+		StartBlockNumber: 1,
+		NumBlock:         4,
+		mu:               &sync.Mutex{},
+		Cache:            cache,
+		Offline:          true,
+		Breakdown:        breakdown,
+	}
+	require.NoError(t, tm.MetricByBlock())
+	// block 1 has no tx, the range is block 2 to block 2+NumBlock
+	assert.Equal(t, int64(1+2+3+4+5), tm.Summary.TotalTx)
+	assert.Equal(t, int64(5), tm.Summary.TotalBlock)
+	assert.Equal(t, uint64(8), tm.Summary.Duration)
+	assert.Equal(t, int64(15), breakdown.Txs)
+
+	_, err = tm.block(7)
+	assert.Equal(t, ErrNotCached, errors.Cause(err))
+}
@@ -0,0 +1,116 @@
+package tx_metric
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/evrclient"
+
+	"github.com/evrynet-official/evrynet-tools/lib/node"
+)
+
+// CapturedTx is a tx taken from a recorded block range, with its time relative to the first block of the range
+type CapturedTx struct {
+	Block    uint64          `json:"block"`
+	Offset   time.Duration   `json:"offset"`
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to"`
+	Value    *hexutil.Big    `json:"value"`
+	Gas      uint64          `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	Data     hexutil.Bytes   `json:"data"`
+	// Nonce is the nonce of the tx on the captured network, it gives the address of the contract a tx creates
+	Nonce uint64 `json:"nonce"`
+}
+
+// Capture extracts the txs of blocks from to to (inclusive) in order. Block timestamps only have a precision
+// of a second, so the txs of a block are spread evenly over the time until the next block.
+func Capture(client *evrclient.Client, from, to uint64) ([]CapturedTx, error) {
+	if to < from {
+		return nil, fmt.Errorf("invalid block range %d - %d", from, to)
+	}
+	var (
+		captured []CapturedTx
+		start    uint64
+		next     *types.Block
+	)
+	bl, err := FetchBlock(client, from)
+	if err != nil {
+		return nil, err
+	}
+	start = bl.Time()
+	for number := from; number <= to; number++ {
+		if number < to {
+			if next, err = FetchBlock(client, number+1); err != nil {
+				return nil, err
+			}
+		}
+		var (
+			offset = time.Duration(bl.Time()-start) * time.Second
+			span   = time.Second
+		)
+		if number < to && next.Time() > bl.Time() {
+			span = time.Duration(next.Time()-bl.Time()) * time.Second
+		}
+		txs := bl.Transactions()
+		fmt.Printf("Found blocknumber %d | Txs: %d\n", number, txs.Len())
+		for i, tx := range txs {
+			sender, err := node.TxSender(tx)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to recover sender of tx %s", tx.Hash().Hex())
+			}
+			captured = append(captured, CapturedTx{
+				Block:    number,
+				Offset:   offset + span*time.Duration(i)/time.Duration(txs.Len()),
+				From:     sender,
+				To:       tx.To(),
+				Value:    (*hexutil.Big)(tx.Value()),
+				Gas:      tx.Gas(),
+				GasPrice: (*hexutil.Big)(tx.GasPrice()),
+				Data:     tx.Data(),
+				Nonce:    tx.Nonce(),
+			})
+		}
+		bl = next
+	}
+	return captured, nil
+}
+
+// WriteCapture writes captured txs to w as one JSON object per line
+func WriteCapture(w io.Writer, txs []CapturedTx) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, tx := range txs {
+		if err := enc.Encode(tx); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadCapture reads the txs written by WriteCapture
+func ReadCapture(r io.Reader) ([]CapturedTx, error) {
+	var (
+		txs []CapturedTx
+		dec = json.NewDecoder(r)
+	)
+	for {
+		var tx CapturedTx
+		err := dec.Decode(&tx)
+		if err == io.EOF {
+			return txs, nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid captured tx %d", len(txs)+1)
+		}
+		txs = append(txs, tx)
+	}
+}
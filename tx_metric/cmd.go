@@ -1,11 +1,21 @@
 package tx_metric
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/pkg/errors"
+
 	"github.com/urfave/cli"
 
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/evrclient"
+	"github.com/Evrynetlabs/evrynet-node/rpc"
+
 	"github.com/evrynet-official/evrynet-tools/lib/node"
 )
 
@@ -13,11 +23,45 @@ const (
 	startBlockNumber = "start-block"
 	numberOfBlock    = "num-block"
 	duration         = "duration"
+	endBlockNumber   = "end-block"
+	outFile          = "out"
+	windowsFlag      = "windows"
+	refreshFlag      = "refresh"
+	exportFlag       = "export"
+	exportFormatFlag = "export-format"
+	breakdownFlag    = "breakdown"
+	topFlag          = "top"
+	abiFlag          = "abi"
+	cacheFlag        = "cache"
+	offlineFlag      = "offline"
+	receiptsFlag     = "receipts"
+	workersFlag      = "workers"
+	batchSizeFlag    = "batch-size"
+	fromFlag         = "from"
+	toFlag           = "to"
+	stakingScFlag    = "stakingsc"
 )
 
+var cacheDirFlag = cli.StringFlag{
+	Name:  cacheFlag,
+	Usage: "Directory of the block cache, blocks, receipts and proposers are fetched from the node once and read from the cache after",
+}
+
+var fetchFlags = []cli.Flag{
+	cli.IntFlag{
+		Name:  workersFlag,
+		Usage: "Number of batches of blocks fetched at the same time",
+		Value: DefaultWorkers,
+	}, cli.IntFlag{
+		Name:  batchSizeFlag,
+		Usage: "Number of blocks or receipts fetched by a request",
+		Value: DefaultBatchSize,
+	},
+}
+
 // NewTxMetricFlags return flags to tx metric
 func NewTxMetricFlags() []cli.Flag {
-	return []cli.Flag{
+	return append([]cli.Flag{
 		cli.Uint64Flag{
 			Name:  startBlockNumber,
 			Usage: "Where blocknumber start at",
@@ -30,8 +74,29 @@ func NewTxMetricFlags() []cli.Flag {
 			Name:  numberOfBlock,
 			Usage: "Duration to calculate metric",
 			Value: 60,
+		}, cli.BoolFlag{
+			Name:  breakdownFlag,
+			Usage: "Classify the txs by kind, contract and method, and count the txs of every sender, from their receipts",
+		}, cli.IntFlag{
+			Name:  topFlag,
+			Usage: "Number of classes and senders of the breakdown printed",
+			Value: 10,
+		}, cli.StringSliceFlag{
+			Name:  abiFlag,
+			Usage: "JSON file of the ABI of a contract whose methods are decoded by the breakdown, besides the staking contract",
 		},
-	}
+		cacheDirFlag,
+		cli.BoolFlag{
+			Name:  offlineFlag,
+			Usage: "Read blocks from the cache only, without the node",
+		}, cli.StringFlag{
+			Name:  fromFlag,
+			Usage: "Time the range starts at instead of --start-block, as RFC3339 (2020-04-14T09:20:00Z) or relative to now (-2h)",
+		}, cli.StringFlag{
+			Name:  toFlag,
+			Usage: "Time the range ends at instead of --duration or --num-block, as RFC3339 or relative to now, requires --from",
+		},
+	}, fetchFlags...)
 }
 
 // NewTxMetricFromFlags will init metric flags
@@ -42,11 +107,310 @@ func NewTxMetricFromFlags(ctx *cli.Context) (tm *TxMetric, err error) {
 		NumBlock:         ctx.Uint64(numberOfBlock),
 		mu:               &sync.Mutex{},
 		minuteStats:      []int64{},
+		Top:              ctx.Int(topFlag),
+	}
+	if ctx.Bool(breakdownFlag) {
+		abis, err := LoadABIs(ctx.StringSlice(abiFlag))
+		if err != nil {
+			return nil, err
+		}
+		if tm.Breakdown, err = NewBreakdown(abis...); err != nil {
+			return nil, err
+		}
 	}
 
-	tm.EvrClient, err = node.NewEvrynetClientFromFlags(ctx)
-	if err != nil {
+	if err := tm.timeRangeFromFlags(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := tm.dialFromFlags(ctx); err != nil {
+		return nil, err
+	}
+	if err := tm.openCacheFromFlags(ctx); err != nil {
 		return nil, err
 	}
 	return tm, nil
 }
+
+// timeRangeFromFlags sets From and To from flags
+func (tm *TxMetric) timeRangeFromFlags(ctx *cli.Context) (err error) {
+	now := time.Now()
+	if from := ctx.String(fromFlag); from != "" {
+		if tm.From, err = ParseTime(from, now); err != nil {
+			return err
+		}
+	}
+	if to := ctx.String(toFlag); to != "" {
+		if tm.From.IsZero() {
+			return errors.Errorf("--%s requires --%s", toFlag, fromFlag)
+		}
+		if tm.To, err = ParseTime(to, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dialFromFlags connects to the node of flags, with the RPC client the batch requests are sent by
+func (tm *TxMetric) dialFromFlags(ctx *cli.Context) (err error) {
+	tm.Workers = ctx.Int(workersFlag)
+	tm.BatchSize = ctx.Int(batchSizeFlag)
+	if tm.RPC, err = rpc.Dial(node.EndpointFromFlags(ctx)); err != nil {
+		return err
+	}
+	tm.EvrClient = evrclient.NewClient(tm.RPC)
+	return nil
+}
+
+// openCacheFromFlags opens the cache of flags, if it is set, and checks it holds blocks of the chain of the node
+func (tm *TxMetric) openCacheFromFlags(ctx *cli.Context) (err error) {
+	tm.Offline = ctx.Bool(offlineFlag)
+	path := ctx.String(cacheFlag)
+	if path == "" {
+		if tm.Offline {
+			return errors.Errorf("--%s requires --%s", offlineFlag, cacheFlag)
+		}
+		return nil
+	}
+	if tm.Cache, err = OpenCache(path); err != nil {
+		return err
+	}
+	if tm.Offline {
+		return nil
+	}
+	genesis, err := tm.EvrClient.HeaderByNumber(context.Background(), common.Big0)
+	if err == nil {
+		err = tm.Cache.CheckGenesis(genesis.Hash())
+	}
+	if err != nil {
+		tm.Cache.Close()
+		return err
+	}
+	return nil
+}
+
+// NewFillFlags return flags to fill the block cache
+func NewFillFlags() []cli.Flag {
+	return append([]cli.Flag{
+		cli.Uint64Flag{
+			Name:  startBlockNumber,
+			Usage: "First block to cache",
+			Value: 0,
+		}, cli.Uint64Flag{
+			Name:  endBlockNumber,
+			Usage: "Last block to cache",
+			Value: 0,
+		}, cli.BoolFlag{
+			Name:  receiptsFlag,
+			Usage: "Cache the receipts of the txs too, which the breakdown needs",
+		},
+		cacheDirFlag,
+	}, fetchFlags...)
+}
+
+// FillFromFlags caches the block range of flags
+func FillFromFlags(ctx *cli.Context) error {
+	if ctx.String(cacheFlag) == "" {
+		return errors.Errorf("--%s is required", cacheFlag)
+	}
+	tm := &TxMetric{mu: &sync.Mutex{}}
+	if err := tm.dialFromFlags(ctx); err != nil {
+		return err
+	}
+	if err := tm.openCacheFromFlags(ctx); err != nil {
+		return err
+	}
+	defer tm.Close()
+	return tm.Fill(ctx.Uint64(startBlockNumber), ctx.Uint64(endBlockNumber), ctx.Bool(receiptsFlag))
+}
+
+// NewValidatorsFlags return flags to report the proposers of a block range
+func NewValidatorsFlags() []cli.Flag {
+	return append([]cli.Flag{
+		cli.Uint64Flag{
+			Name:  startBlockNumber,
+			Usage: "First block of the range",
+			Value: 0,
+		}, cli.Uint64Flag{
+			Name:  endBlockNumber,
+			Usage: "Last block of the range, the latest block when it is not set",
+			Value: 0,
+		}, cli.StringFlag{
+			Name:  fromFlag,
+			Usage: "Time the range starts at instead of --start-block, as RFC3339 (2020-04-14T09:20:00Z) or relative to now (-2h)",
+		}, cli.StringFlag{
+			Name:  toFlag,
+			Usage: "Time the range ends at instead of --end-block, as RFC3339 or relative to now, now when it is not set",
+		}, cli.StringFlag{
+			Name:  stakingScFlag,
+			Usage: "Address of the staking contract the proposers are compared with the candidates of",
+			Value: "0x2d5bd25efa0ab97aaca4e888c5fbcb4866904e46",
+		},
+		cacheDirFlag,
+		cli.BoolFlag{
+			Name:  offlineFlag,
+			Usage: "Read blocks and proposers from the cache only, without the node nor the candidates",
+		},
+	}, fetchFlags...)
+}
+
+// ValidatorsFromFlags returns the proposers of the block range of flags
+func ValidatorsFromFlags(ctx *cli.Context) (*Validators, error) {
+	stakingSc := ctx.String(stakingScFlag)
+	if !common.IsHexAddress(stakingSc) {
+		return nil, errors.New("the address of staking sc is invalid")
+	}
+	tm := &TxMetric{mu: &sync.Mutex{}}
+	if err := tm.timeRangeFromFlags(ctx); err != nil {
+		return nil, err
+	}
+	if err := tm.dialFromFlags(ctx); err != nil {
+		return nil, err
+	}
+	if err := tm.openCacheFromFlags(ctx); err != nil {
+		return nil, err
+	}
+	defer tm.Close()
+
+	first, last := ctx.Uint64(startBlockNumber), ctx.Uint64(endBlockNumber)
+	if !tm.From.IsZero() {
+		if tm.To.IsZero() {
+			tm.To = time.Now()
+		}
+		if err := tm.FindRange(); err != nil {
+			return nil, err
+		}
+		first, last = tm.StartBlockNumber, tm.StartBlockNumber+tm.NumBlock
+	} else if last == 0 {
+		head, err := tm.head()
+		if err != nil {
+			return nil, err
+		}
+		last = head
+	}
+
+	var candidates []common.Address
+	if !tm.Offline {
+		var err error
+		if candidates, err = Candidates(tm.EvrClient, common.HexToAddress(stakingSc)); err != nil {
+			return nil, err
+		}
+	}
+	return tm.Validators(first, last, candidates)
+}
+
+// NewCaptureFlags return flags to capture the txs of a block range
+func NewCaptureFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.Uint64Flag{
+			Name:  startBlockNumber,
+			Usage: "First block of the range to capture",
+			Value: 0,
+		}, cli.Uint64Flag{
+			Name:  endBlockNumber,
+			Usage: "Last block of the range to capture",
+			Value: 0,
+		}, cli.StringFlag{
+			Name:  outFile,
+			Usage: "File the captured txs are written to",
+			Value: "capture.jsonl",
+		},
+	}
+}
+
+// CaptureFromFlags captures the txs of the block range of flags and writes them to the out file
+func CaptureFromFlags(ctx *cli.Context) (int, error) {
+	client, err := node.NewEvrynetClientFromFlags(ctx)
+	if err != nil {
+		return 0, err
+	}
+	txs, err := Capture(client, ctx.Uint64(startBlockNumber), ctx.Uint64(endBlockNumber))
+	if err != nil {
+		return 0, err
+	}
+
+	file, err := os.Create(ctx.String(outFile))
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	return len(txs), WriteCapture(file, txs)
+}
+
+// NewLiveFlags return flags to follow new blocks
+func NewLiveFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  windowsFlag,
+			Usage: "Comma separated spans of the rolling stats",
+			Value: "10s,1m,5m",
+		}, cli.DurationFlag{
+			Name:  refreshFlag,
+			Usage: "Time between two refreshes of the view",
+			Value: time.Second,
+		}, cli.DurationFlag{
+			Name:  duration,
+			Usage: "Time to follow new blocks for, 0 to follow them until interrupted",
+			Value: 0,
+		},
+	}
+}
+
+// NewLiveFromFlags returns a live view of the node of flags
+func NewLiveFromFlags(ctx *cli.Context) (*Live, error) {
+	var windows []time.Duration
+	for _, value := range strings.Split(ctx.String(windowsFlag), ",") {
+		span, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid window %q", value)
+		}
+		if span <= 0 {
+			return nil, errors.Errorf("invalid window %q, it has to be positive", value)
+		}
+		windows = append(windows, span)
+	}
+
+	client, err := node.NewEvrynetClientFromFlags(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Live{
+		EvrClient: client,
+		Windows:   windows,
+		Refresh:   ctx.Duration(refreshFlag),
+	}, nil
+}
+
+// NewExportFlags return flags to export the blocks and summary of a metric
+func NewExportFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  exportFlag,
+			Usage: "File the blocks and summary are exported to",
+		}, cli.StringFlag{
+			Name:  exportFormatFlag,
+			Usage: "Format of the export: csv, json or influx, taken from the extension of the file when it is not set",
+		},
+	}
+}
+
+// ExportFromFlags exports the blocks and summary of the last metric of tm to the file of flags, if it is set
+func ExportFromFlags(ctx *cli.Context, tm *TxMetric, tool string) error {
+	path := ctx.String(exportFlag)
+	if path == "" {
+		return nil
+	}
+	format, err := FormatOf(path, ctx.String(exportFormatFlag))
+	if err != nil {
+		return err
+	}
+	export, err := tm.Export(tool)
+	if err != nil {
+		return err
+	}
+	if err := export.WriteFile(path, format); err != nil {
+		return err
+	}
+	fmt.Printf("%d blocks exported to %s\n", len(export.Blocks), path)
+	return nil
+}
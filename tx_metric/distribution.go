@@ -0,0 +1,187 @@
+package tx_metric
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+)
+
+const (
+	// numGaps is the number of largest gaps between blocks reported
+	numGaps = 5
+	// maxHistogramSeconds is the block time from which blocks share the last bucket of the histogram
+	maxHistogramSeconds = 20
+	histogramWidth      = 40
+)
+
+// blockStat is what the distributions keep of a block
+type blockStat struct {
+	number   uint64
+	time     uint64
+	txs      int64
+	gasUsed  uint64
+	gasLimit uint64
+	// block and receipts are kept until the txs of the block are added to the breakdown
+	block    *types.Block
+	receipts []*types.Receipt
+}
+
+func newBlockStat(bl *types.Block) blockStat {
+	return blockStat{
+		number:   bl.NumberU64(),
+		time:     bl.Time(),
+		txs:      int64(bl.Transactions().Len()),
+		gasUsed:  bl.GasUsed(),
+		gasLimit: bl.GasLimit(),
+	}
+}
+
+// Percentiles summarize the spread of a value over blocks
+type Percentiles struct {
+	Min float64
+	P50 float64
+	P90 float64
+	P95 float64
+	P99 float64
+	Max float64
+}
+
+// newPercentiles returns the nearest-rank percentiles of values, which it sorts
+func newPercentiles(values []float64) Percentiles {
+	if len(values) == 0 {
+		return Percentiles{}
+	}
+	sort.Float64s(values)
+	rank := func(p float64) float64 {
+		i := int(math.Ceil(p/100*float64(len(values)))) - 1
+		if i < 0 {
+			i = 0
+		}
+		return values[i]
+	}
+	return Percentiles{
+		Min: values[0],
+		P50: rank(50),
+		P90: rank(90),
+		P95: rank(95),
+		P99: rank(99),
+		Max: values[len(values)-1],
+	}
+}
+
+func (p Percentiles) String() string {
+	return fmt.Sprintf("min %.2f | p50 %.2f | p90 %.2f | p95 %.2f | p99 %.2f | max %.2f", p.Min, p.P50, p.P90, p.P95, p.P99, p.Max)
+}
+
+// Gap is the time between two consecutive blocks
+type Gap struct {
+	From    uint64
+	To      uint64
+	Seconds uint64
+}
+
+// Distribution is the spread of block time, txs and gas usage over a range of blocks. Averages hide stalls,
+// the percentiles, histogram and largest gaps show them.
+type Distribution struct {
+	// BlockTime is the time in seconds between a block and the one before, the first block of the range has none
+	BlockTime Percentiles
+	Txs       Percentiles
+	// GasUsage is the ratio of its gas limit a block used
+	GasUsage Percentiles
+	// Histogram is the number of blocks by block time in seconds, the last bucket holds every block time
+	// from maxHistogramSeconds
+	Histogram []int
+	// Gaps are the largest block times, largest first
+	Gaps []Gap
+}
+
+// newDistribution returns the distribution of blocks, which may be in any order
+func newDistribution(blocks []blockStat) *Distribution {
+	sorted := make([]blockStat, len(blocks))
+	copy(sorted, blocks)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].number < sorted[j].number })
+
+	var (
+		dist       = &Distribution{}
+		blockTimes []float64
+		txs        []float64
+		gasUsage   []float64
+		gaps       []Gap
+	)
+	for i, bl := range sorted {
+		txs = append(txs, float64(bl.txs))
+		if bl.gasLimit != 0 {
+			gasUsage = append(gasUsage, float64(bl.gasUsed)/float64(bl.gasLimit))
+		}
+		if i == 0 || bl.number != sorted[i-1].number+1 {
+			continue
+		}
+		var seconds uint64
+		if prev := sorted[i-1].time; bl.time > prev {
+			seconds = bl.time - prev
+		}
+		blockTimes = append(blockTimes, float64(seconds))
+		gaps = append(gaps, Gap{From: sorted[i-1].number, To: bl.number, Seconds: seconds})
+
+		bucket := int(seconds)
+		if bucket > maxHistogramSeconds {
+			bucket = maxHistogramSeconds
+		}
+		for bucket >= len(dist.Histogram) {
+			dist.Histogram = append(dist.Histogram, 0)
+		}
+		dist.Histogram[bucket]++
+	}
+	dist.BlockTime = newPercentiles(blockTimes)
+	dist.Txs = newPercentiles(txs)
+	dist.GasUsage = newPercentiles(gasUsage)
+
+	sort.SliceStable(gaps, func(i, j int) bool { return gaps[i].Seconds > gaps[j].Seconds })
+	if len(gaps) > numGaps {
+		gaps = gaps[:numGaps]
+	}
+	dist.Gaps = gaps
+	return dist
+}
+
+// Print prints the distributions on console view
+func (d *Distribution) Print() {
+	fmt.Println("-----------Distribution Stats----------------")
+	fmt.Println("Block time (s):", d.BlockTime)
+	fmt.Println("Txs/Block:", d.Txs)
+	fmt.Println("Gas used/Gas limit (%):", Percentiles{
+		Min: d.GasUsage.Min * 100,
+		P50: d.GasUsage.P50 * 100,
+		P90: d.GasUsage.P90 * 100,
+		P95: d.GasUsage.P95 * 100,
+		P99: d.GasUsage.P99 * 100,
+		Max: d.GasUsage.Max * 100,
+	})
+
+	var most int
+	for _, n := range d.Histogram {
+		if n > most {
+			most = n
+		}
+	}
+	if most != 0 {
+		fmt.Println("Block time histogram:")
+		for seconds, n := range d.Histogram {
+			label := fmt.Sprintf("%ds", seconds)
+			if seconds == maxHistogramSeconds {
+				label = fmt.Sprintf(">=%ds", seconds)
+			}
+			bar := strings.Repeat("#", (n*histogramWidth+most-1)/most)
+			fmt.Printf("%6s %6d %s\n", label, n, bar)
+		}
+	}
+	if len(d.Gaps) != 0 {
+		fmt.Println("Largest gaps between blocks:")
+		for _, gap := range d.Gaps {
+			fmt.Printf("Block %d => %d: %ds\n", gap.From, gap.To, gap.Seconds)
+		}
+	}
+}
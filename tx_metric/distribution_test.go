@@ -0,0 +1,48 @@
+package tx_metric
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPercentiles(t *testing.T) {
+	var values []float64
+	for i := 100; i >= 1; i-- {
+		values = append(values, float64(i))
+	}
+	p := newPercentiles(values)
+	assert.Equal(t, Percentiles{Min: 1, P50: 50, P90: 90, P95: 95, P99: 99, Max: 100}, p)
+	assert.Equal(t, Percentiles{Min: 7, P50: 7, P90: 7, P95: 7, P99: 7, Max: 7}, newPercentiles([]float64{7}))
+	assert.Equal(t, Percentiles{}, newPercentiles(nil))
+}
+
+func TestNewDistribution(t *testing.T) {
+	// blocks fetched concurrently arrive in any order, block 5 comes 30s after block 4
+	blocks := []blockStat{
+		{number: 3, time: 102, txs: 4, gasUsed: 40, gasLimit: 100},
+		{number: 1, time: 100, txs: 0, gasUsed: 0, gasLimit: 100},
+		{number: 5, time: 133, txs: 10, gasUsed: 100, gasLimit: 100},
+		{number: 2, time: 101, txs: 2, gasUsed: 20, gasLimit: 100},
+		{number: 4, time: 103, txs: 6, gasUsed: 60, gasLimit: 100},
+	}
+	d := newDistribution(blocks)
+
+	assert.Equal(t, float64(1), d.BlockTime.Min)
+	assert.Equal(t, float64(1), d.BlockTime.P50)
+	assert.Equal(t, float64(30), d.BlockTime.Max)
+	assert.Equal(t, float64(4), d.Txs.P50)
+	assert.Equal(t, float64(10), d.Txs.Max)
+	assert.Equal(t, 0.4, d.GasUsage.P50)
+	assert.Equal(t, float64(1), d.GasUsage.Max)
+
+	require.Len(t, d.Histogram, maxHistogramSeconds+1)
+	assert.Equal(t, 3, d.Histogram[1])
+	assert.Equal(t, 1, d.Histogram[maxHistogramSeconds])
+
+	require.Len(t, d.Gaps, 4)
+	assert.Equal(t, Gap{From: 4, To: 5, Seconds: 30}, d.Gaps[0])
+	assert.Equal(t, Gap{From: 1, To: 2, Seconds: 1}, d.Gaps[1])
+	d.Print()
+}
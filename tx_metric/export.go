@@ -0,0 +1,217 @@
+package tx_metric
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatJSON   = "json"
+	FormatInflux = "influx"
+)
+
+// BlockRow is a block of an export
+type BlockRow struct {
+	Number   uint64          `json:"number"`
+	Time     uint64          `json:"time"`
+	Txs      int64           `json:"txs"`
+	GasUsed  uint64          `json:"gas_used"`
+	GasLimit uint64          `json:"gas_limit"`
+	Proposer *common.Address `json:"proposer"`
+}
+
+// Export is the blocks and the summary of a metric, written as CSV, JSON or InfluxDB line protocol
+type Export struct {
+	Tool    string             `json:"tool"`
+	Summary map[string]float64 `json:"summary"`
+	Blocks  []BlockRow         `json:"blocks"`
+}
+
+// Export returns the blocks of the last MetricByTime or MetricByBlock in order with their proposers,
+// which are looked up in batches of consecutive blocks
+func (tm *TxMetric) Export(tool string) (*Export, error) {
+	blocks := make([]blockStat, len(tm.blocks))
+	copy(blocks, tm.blocks)
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].number < blocks[j].number })
+
+	export := &Export{Tool: tool, Summary: tm.Summary.Run(tool).Metrics}
+	for start := 0; start < len(blocks); {
+		end := start + 1
+		for end < len(blocks) && blocks[end].number == blocks[end-1].number+1 && uint64(end-start) < tm.chunkSize() {
+			end++
+		}
+		proposers, err := tm.proposers(blocks[start].number, blocks[end-1].number)
+		if err != nil {
+			return nil, err
+		}
+		for i, bl := range blocks[start:end] {
+			row := BlockRow{
+				Number:   bl.number,
+				Time:     bl.time,
+				Txs:      bl.txs,
+				GasUsed:  bl.gasUsed,
+				GasLimit: bl.gasLimit,
+			}
+			if proposers[i] != (common.Address{}) {
+				row.Proposer = &proposers[i]
+			}
+			export.Blocks = append(export.Blocks, row)
+		}
+		start = end
+	}
+	return export, nil
+}
+
+// FormatOf returns format, or the format of the extension of path when format is empty
+func FormatOf(path, format string) (string, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".json":
+			format = FormatJSON
+		case ".influx", ".lp":
+			format = FormatInflux
+		default:
+			format = FormatCSV
+		}
+	}
+	switch format {
+	case FormatCSV, FormatJSON, FormatInflux:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown export format %q, use %s, %s or %s", format, FormatCSV, FormatJSON, FormatInflux)
+}
+
+// WriteFile writes the export to path. A CSV export holds the blocks and its summary is written next to it,
+// to the same path with a .summary.csv extension.
+func (e *Export) WriteFile(path, format string) error {
+	format, err := FormatOf(path, format)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case FormatJSON:
+		return writeFile(path, e.WriteJSON)
+	case FormatInflux:
+		return writeFile(path, e.WriteInflux)
+	}
+	if err := writeFile(path, e.WriteCSV); err != nil {
+		return err
+	}
+	return writeFile(strings.TrimSuffix(path, filepath.Ext(path))+".summary.csv", e.WriteSummaryCSV)
+}
+
+// writeFile creates path and writes it with write, failing when the file can not be closed
+// so that a truncated file is not taken for a complete one
+func writeFile(path string, write func(w io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WriteCSV writes a row per block
+func (e *Export) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"number", "time", "txs", "gas_used", "gas_limit", "proposer"}); err != nil {
+		return err
+	}
+	for _, bl := range e.Blocks {
+		var proposer string
+		if bl.Proposer != nil {
+			proposer = bl.Proposer.Hex()
+		}
+		err := cw.Write([]string{
+			strconv.FormatUint(bl.Number, 10),
+			strconv.FormatUint(bl.Time, 10),
+			strconv.FormatInt(bl.Txs, 10),
+			strconv.FormatUint(bl.GasUsed, 10),
+			strconv.FormatUint(bl.GasLimit, 10),
+			proposer,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteSummaryCSV writes a row per metric of the summary
+func (e *Export) WriteSummaryCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"metric", "value"}); err != nil {
+		return err
+	}
+	for _, name := range e.metricNames() {
+		if err := cw.Write([]string{name, strconv.FormatFloat(e.Summary[name], 'f', -1, 64)}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes the export as a JSON document
+func (e *Export) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(e)
+}
+
+// WriteInflux writes a tx_metric_block point per block and a tx_metric_summary point at the time of the last block
+func (e *Export) WriteInflux(w io.Writer) error {
+	for _, bl := range e.Blocks {
+		tags := ""
+		if bl.Proposer != nil {
+			tags = ",proposer=" + bl.Proposer.Hex()
+		}
+		_, err := fmt.Fprintf(w, "tx_metric_block%s number=%di,txs=%di,gas_used=%di,gas_limit=%di %d\n",
+			tags, bl.Number, bl.Txs, bl.GasUsed, bl.GasLimit, int64(bl.Time)*1e9)
+		if err != nil {
+			return err
+		}
+	}
+	names := e.metricNames()
+	if len(names) == 0 {
+		return nil
+	}
+	var fields []string
+	for _, name := range names {
+		fields = append(fields, fmt.Sprintf("%s=%s", name, strconv.FormatFloat(e.Summary[name], 'f', -1, 64)))
+	}
+	line := fmt.Sprintf("tx_metric_summary,tool=%s %s", escapeTag(e.Tool), strings.Join(fields, ","))
+	if len(e.Blocks) != 0 {
+		line += fmt.Sprintf(" %d", int64(e.Blocks[len(e.Blocks)-1].Time)*1e9)
+	}
+	_, err := fmt.Fprintln(w, line)
+	return err
+}
+
+func (e *Export) metricNames() []string {
+	var names []string
+	for name := range e.Summary {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// escapeTag escapes the characters of a tag value of the line protocol
+func escapeTag(value string) string {
+	return strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `).Replace(value)
+}
@@ -0,0 +1,105 @@
+package tx_metric
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+)
+
+func testExport() *Export {
+	proposer := common.HexToAddress("0x45F8B547A7f16730c0C8961A21b56c31d84DdB49")
+	return &Export{
+		Tool:    "tx_metric byblock",
+		Summary: map[string]float64{"tps": 2.5, "total_tx": 5},
+		Blocks: []BlockRow{
+			{Number: 10, Time: 100, Txs: 0, GasUsed: 0, GasLimit: 1000, Proposer: &proposer},
+			{Number: 11, Time: 102, Txs: 5, GasUsed: 105000, GasLimit: 1000000},
+		},
+	}
+}
+
+func TestExport_WriteCSV(t *testing.T) {
+	e := testExport()
+	buf := &bytes.Buffer{}
+	require.NoError(t, e.WriteCSV(buf))
+	assert.Equal(t, "number,time,txs,gas_used,gas_limit,proposer\n"+
+		"10,100,0,0,1000,0x45F8B547A7f16730c0C8961A21b56c31d84DdB49\n"+
+		"11,102,5,105000,1000000,\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, e.WriteSummaryCSV(buf))
+	assert.Equal(t, "metric,value\ntotal_tx,5\ntps,2.5\n", buf.String())
+}
+
+func TestExport_WriteInflux(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, testExport().WriteInflux(buf))
+	assert.Equal(t, "tx_metric_block,proposer=0x45F8B547A7f16730c0C8961A21b56c31d84DdB49 number=10i,txs=0i,gas_used=0i,gas_limit=1000i 100000000000\n"+
+		"tx_metric_block number=11i,txs=5i,gas_used=105000i,gas_limit=1000000i 102000000000\n"+
+		`tx_metric_summary,tool=tx_metric\ byblock total_tx=5,tps=2.5 102000000000`+"\n", buf.String())
+}
+
+func TestExport_WriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "export")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	e := testExport()
+	path := filepath.Join(dir, "blocks.json")
+	require.NoError(t, e.WriteFile(path, ""))
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	var read Export
+	require.NoError(t, json.Unmarshal(data, &read))
+	assert.Equal(t, *e, read)
+
+	path = filepath.Join(dir, "blocks.csv")
+	require.NoError(t, e.WriteFile(path, ""))
+	_, err = os.Stat(filepath.Join(dir, "blocks.summary.csv"))
+	assert.NoError(t, err)
+
+	assert.Error(t, e.WriteFile(path, "xml"))
+}
+
+func TestFormatOf(t *testing.T) {
+	for path, expected := range map[string]string{
+		"run.json":   FormatJSON,
+		"run.influx": FormatInflux,
+		"run.lp":     FormatInflux,
+		"run.csv":    FormatCSV,
+		"run":        FormatCSV,
+	} {
+		format, err := FormatOf(path, "")
+		require.NoError(t, err)
+		assert.Equal(t, expected, format, path)
+	}
+	format, err := FormatOf("run.csv", FormatInflux)
+	require.NoError(t, err)
+	assert.Equal(t, FormatInflux, format)
+}
+
+func TestTxMetric_Export(t *testing.T) {
+	node, tm := newTestNode(t, 6)
+	node.proposers = []common.Address{common.HexToAddress("0x0a"), common.HexToAddress("0x0b")}
+	tm.StartBlockNumber = 1
+	tm.NumBlock = 4
+	require.NoError(t, tm.MetricByBlock())
+
+	export, err := tm.Export("tx_metric byblock")
+	require.NoError(t, err)
+	require.Len(t, export.Blocks, 5)
+	// the range starts at the first block with txs
+	for i, bl := range export.Blocks {
+		assert.Equal(t, uint64(2+i), bl.Number)
+		require.NotNil(t, bl.Proposer)
+		assert.Equal(t, node.proposers[(1+i)%2], *bl.Proposer)
+	}
+}
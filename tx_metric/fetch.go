@@ -0,0 +1,286 @@
+package tx_metric
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/evrclient"
+	"github.com/Evrynetlabs/evrynet-node/rpc"
+)
+
+const (
+	fetchAttempts    = 10
+	DefaultWorkers   = 8
+	DefaultBatchSize = 20
+)
+
+var (
+	// retryBackoff is the wait after the first failed attempt, it doubles after every failure up to maxRetryBackoff
+	retryBackoff    = 100 * time.Millisecond
+	maxRetryBackoff = 5 * time.Second
+)
+
+// retry calls fn until it succeeds, fetchAttempts times at most, waiting longer after every failure.
+// It does not try again when the item does not exist.
+func retry(what string, fn func() error) error {
+	var (
+		err     error
+		backoff = retryBackoff
+	)
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil || isMissing(err) {
+			return err
+		}
+		if attempt == fetchAttempts {
+			return errors.Wrapf(err, "can not get %s after %d attempts", what, attempt)
+		}
+		fmt.Printf("Can not get %s. Error: %s, attempt: %d, trying again in %s\n", what, err, attempt, backoff)
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+// isMissing returns whether err is returned for a block that is not mined yet, or not cached in offline mode
+func isMissing(err error) bool {
+	cause := errors.Cause(err)
+	return cause == evrynet.NotFound || cause == ErrNotCached
+}
+
+// FetchBlock gets a block by number, trying again with a backoff when the node fails to return it
+func FetchBlock(client *evrclient.Client, number uint64) (*types.Block, error) {
+	var bl *types.Block
+	err := retry(fmt.Sprintf("block %d", number), func() (err error) {
+		bl, err = client.BlockByNumber(context.Background(), new(big.Int).SetUint64(number))
+		return err
+	})
+	if isMissing(err) {
+		return nil, errors.Wrapf(err, "block %d", number)
+	}
+	return bl, err
+}
+
+func (tm *TxMetric) workers() int {
+	if tm.Workers <= 0 {
+		return DefaultWorkers
+	}
+	return tm.Workers
+}
+
+func (tm *TxMetric) batchSize() int {
+	if tm.BatchSize <= 0 {
+		return DefaultBatchSize
+	}
+	return tm.BatchSize
+}
+
+// chunkSize is the number of blocks fetched at once, enough to keep every worker busy with a batch
+func (tm *TxMetric) chunkSize() uint64 {
+	return uint64(tm.workers() * tm.batchSize())
+}
+
+// headerOnly returns whether block stats are read from headers, which needs neither the txs of blocks
+// for the breakdown nor full blocks for the cache
+func (tm *TxMetric) headerOnly() bool {
+	return tm.RPC != nil && tm.Cache == nil && tm.Breakdown == nil
+}
+
+// fetchRange returns the stats of the blocks from first to last in order, fetched in batches of BatchSize blocks
+// by Workers workers, with the blocks and their receipts when receipts is set.
+// It stops at the first block that is missing and returns the blocks before it, with an error for which
+// isMissing is true.
+func (tm *TxMetric) fetchRange(first, last uint64, receipts bool) ([]blockStat, error) {
+	if last < first {
+		return nil, nil
+	}
+	var (
+		size     = uint64(tm.batchSize())
+		nBatches = int((last-first)/size) + 1
+		results  = make([][]blockStat, nBatches)
+		errs     = make([]error, nBatches)
+		jobs     = make(chan int)
+		failed   int32
+		wg       sync.WaitGroup
+	)
+	for w := 0; w < tm.workers(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				from := first + uint64(i)*size
+				to := from + size - 1
+				if to > last {
+					to = last
+				}
+				results[i], errs[i] = tm.fetchBatch(from, to, receipts)
+				if errs[i] != nil {
+					atomic.StoreInt32(&failed, 1)
+				}
+			}
+		}()
+	}
+	// batches are dispatched in order, so that every batch before a failed one was fetched
+	for i := 0; i < nBatches && atomic.LoadInt32(&failed) == 0; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var stats []blockStat
+	for i := range results {
+		stats = append(stats, results[i]...)
+		if errs[i] != nil {
+			return stats, errs[i]
+		}
+	}
+	return stats, nil
+}
+
+// fetchBatch returns the stats of the blocks from first to last, the blocks before the first missing one
+// when a block is missing
+func (tm *TxMetric) fetchBatch(first, last uint64, receipts bool) ([]blockStat, error) {
+	if tm.headerOnly() {
+		return tm.fetchHeaders(first, last)
+	}
+	var stats []blockStat
+	for number := first; number <= last; number++ {
+		bl, err := tm.block(number)
+		if err != nil {
+			return stats, err
+		}
+		stat := newBlockStat(bl)
+		if receipts {
+			stat.block = bl
+			if stat.receipts, err = tm.receipts(bl); err != nil {
+				return stats, err
+			}
+		}
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
+
+// rpcBlockTxs is the list of tx hashes of a block returned without the bodies of its txs
+type rpcBlockTxs struct {
+	Transactions []common.Hash `json:"transactions"`
+}
+
+// fetchHeaders returns the stats of the blocks from first to last, read from their headers and tx hashes
+// in a single batch request
+func (tm *TxMetric) fetchHeaders(first, last uint64) ([]blockStat, error) {
+	var (
+		raws  = make([]json.RawMessage, last-first+1)
+		batch = make([]rpc.BatchElem, len(raws))
+	)
+	for i := range batch {
+		batch[i] = rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []interface{}{hexutil.EncodeUint64(first + uint64(i)), false},
+			Result: &raws[i],
+		}
+	}
+	err := retry(fmt.Sprintf("blocks %d to %d", first, last), func() error {
+		if err := tm.RPC.BatchCallContext(context.Background(), batch); err != nil {
+			return err
+		}
+		for _, elem := range batch {
+			if elem.Error != nil {
+				return elem.Error
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]blockStat, 0, len(raws))
+	for i, raw := range raws {
+		number := first + uint64(i)
+		if len(raw) == 0 || string(raw) == "null" {
+			return stats, errors.Wrapf(evrynet.NotFound, "block %d", number)
+		}
+		var (
+			header types.Header
+			txs    rpcBlockTxs
+		)
+		if err := json.Unmarshal(raw, &header); err != nil {
+			return stats, errors.Wrapf(err, "invalid block %d", number)
+		}
+		if err := json.Unmarshal(raw, &txs); err != nil {
+			return stats, errors.Wrapf(err, "invalid txs of block %d", number)
+		}
+		stats = append(stats, blockStat{
+			number:   header.Number.Uint64(),
+			time:     header.Time,
+			txs:      int64(len(txs.Transactions)),
+			gasUsed:  header.GasUsed,
+			gasLimit: header.GasLimit,
+		})
+	}
+	return stats, nil
+}
+
+// fetchReceipts returns the receipts of txs from the node, in batches of BatchSize receipts when it can
+func (tm *TxMetric) fetchReceipts(hashes []common.Hash) ([]*types.Receipt, error) {
+	receipts := make([]*types.Receipt, len(hashes))
+	if tm.RPC == nil {
+		for i, hash := range hashes {
+			err := retry("receipt of tx "+hash.Hex(), func() (err error) {
+				receipts[i], err = tm.EvrClient.TransactionReceipt(context.Background(), hash)
+				return err
+			})
+			if err != nil {
+				return nil, errors.Wrapf(err, "receipt of tx %s", hash.Hex())
+			}
+		}
+		return receipts, nil
+	}
+
+	for first := 0; first < len(hashes); first += tm.batchSize() {
+		last := first + tm.batchSize()
+		if last > len(hashes) {
+			last = len(hashes)
+		}
+		batch := make([]rpc.BatchElem, last-first)
+		for i := range batch {
+			batch[i] = rpc.BatchElem{
+				Method: "eth_getTransactionReceipt",
+				Args:   []interface{}{hashes[first+i]},
+				Result: &receipts[first+i],
+			}
+		}
+		err := retry(fmt.Sprintf("%d receipts", len(batch)), func() error {
+			if err := tm.RPC.BatchCallContext(context.Background(), batch); err != nil {
+				return err
+			}
+			for _, elem := range batch {
+				if elem.Error != nil {
+					return elem.Error
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for i, receipt := range receipts {
+		if receipt == nil {
+			return nil, errors.Wrapf(evrynet.NotFound, "receipt of tx %s", hashes[i].Hex())
+		}
+	}
+	return receipts, nil
+}
@@ -0,0 +1,168 @@
+package tx_metric
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/evrclient"
+	"github.com/Evrynetlabs/evrynet-node/rpc"
+)
+
+// testNode serves a genesis block and test blocks with their receipts, failing the first failures requests
+type testNode struct {
+	mu       sync.Mutex
+	blocks   []*types.Block
+	receipts map[common.Hash]*types.Receipt
+	// proposers propose blocks in turn from block 1
+	proposers []common.Address
+	failures  int
+	calls     int
+}
+
+func (n *testNode) fail() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.calls++
+	if n.failures > 0 {
+		n.failures--
+		return errors.New("node is busy")
+	}
+	return nil
+}
+
+func (n *testNode) GetBlockByNumber(number rpc.BlockNumber, fullTx bool) (map[string]interface{}, error) {
+	if err := n.fail(); err != nil {
+		return nil, err
+	}
+	if number == rpc.LatestBlockNumber {
+		number = rpc.BlockNumber(len(n.blocks) - 1)
+	}
+	if number < 0 || int(number) >= len(n.blocks) {
+		return nil, nil
+	}
+	bl := n.blocks[number]
+	data, err := json.Marshal(bl.Header())
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	var hashes []common.Hash
+	for _, tx := range bl.Transactions() {
+		hashes = append(hashes, tx.Hash())
+	}
+	fields["transactions"] = hashes
+	return fields, nil
+}
+
+func (n *testNode) GetTransactionReceipt(hash common.Hash) (*types.Receipt, error) {
+	if err := n.fail(); err != nil {
+		return nil, err
+	}
+	return n.receipts[hash], nil
+}
+
+func newTestNode(t *testing.T, numBlocks int) (*testNode, *TxMetric) {
+	blocks, receipts := testBlocks(t, numBlocks)
+	genesis := types.NewBlock(&types.Header{Number: common.Big0, GasLimit: 1000000, Difficulty: common.Big1}, nil, nil, nil)
+	node := &testNode{blocks: append([]*types.Block{genesis}, blocks...), receipts: make(map[common.Hash]*types.Receipt)}
+	for _, receipt := range receipts {
+		receipt.Logs = []*types.Log{}
+		node.receipts[receipt.TxHash] = receipt
+	}
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", node))
+	client := rpc.DialInProc(server)
+	return node, &TxMetric{
+		mu:        &sync.Mutex{},
+		RPC:       client,
+		EvrClient: evrclient.NewClient(client),
+		Workers:   3,
+		BatchSize: 2,
+	}
+}
+
+func TestTxMetric_fetchRange(t *testing.T) {
+	_, tm := newTestNode(t, 9)
+
+	stats, err := tm.fetchRange(2, 8, false)
+	require.NoError(t, err)
+	require.Len(t, stats, 7)
+	for i, stat := range stats {
+		assert.Equal(t, uint64(2+i), stat.number)
+		assert.Equal(t, int64(1+i), stat.txs)
+		assert.Equal(t, uint64(104+2*i), stat.time)
+	}
+
+	// the blocks before the first missing one are returned
+	stats, err = tm.fetchRange(7, 14, false)
+	assert.True(t, isMissing(err))
+	assert.Len(t, stats, 3)
+}
+
+func TestTxMetric_fetchReceipts(t *testing.T) {
+	node, tm := newTestNode(t, 4)
+	bl := node.blocks[4]
+
+	receipts, err := tm.receipts(bl)
+	require.NoError(t, err)
+	require.Len(t, receipts, 3)
+	for i, receipt := range receipts {
+		assert.Equal(t, bl.Transactions()[i].Hash(), receipt.TxHash)
+	}
+
+	_, err = tm.fetchReceipts([]common.Hash{common.HexToHash("0x01")})
+	assert.True(t, isMissing(err))
+}
+
+func TestRetry(t *testing.T) {
+	defer func(backoff time.Duration) { retryBackoff = backoff }(retryBackoff)
+	retryBackoff = time.Millisecond
+
+	node, tm := newTestNode(t, 3)
+	node.failures = 3
+	stats, err := tm.fetchRange(1, 1, false)
+	require.NoError(t, err)
+	assert.Len(t, stats, 1)
+	assert.Equal(t, 4, node.calls)
+
+	node.failures = fetchAttempts
+	_, err = tm.fetchRange(1, 1, false)
+	assert.Error(t, err)
+	assert.False(t, isMissing(err))
+
+	// a missing block is not asked for again
+	node.calls = 0
+	err = retry("block", func() error {
+		node.calls++
+		return evrynet.NotFound
+	})
+	assert.Equal(t, evrynet.NotFound, err)
+	assert.Equal(t, 1, node.calls)
+}
+
+func TestTxMetric_MetricByBlock(t *testing.T) {
+	_, tm := newTestNode(t, 6)
+	tm.StartBlockNumber = 1
+	tm.NumBlock = 4
+	require.NoError(t, tm.MetricByBlock())
+	assert.Equal(t, int64(1+2+3+4+5), tm.Summary.TotalTx)
+	assert.Equal(t, int64(5), tm.Summary.TotalBlock)
+
+	// the range ends after the last block, the error is returned
+	_, tm = newTestNode(t, 6)
+	tm.StartBlockNumber = 1
+	tm.NumBlock = 10
+	assert.True(t, isMissing(tm.MetricByBlock()))
+}
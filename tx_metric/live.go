@@ -0,0 +1,257 @@
+package tx_metric
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Evrynetlabs/evrynet-node/core/types"
+	"github.com/Evrynetlabs/evrynet-node/evrclient"
+
+	"github.com/evrynet-official/evrynet-tools/lib/node"
+)
+
+// clearScreen moves the cursor home and clears the terminal before the view is printed again
+const clearScreen = "\033[H\033[2J"
+
+// DefaultWindows are the spans the live view reports rolling stats over
+var DefaultWindows = []time.Duration{10 * time.Second, time.Minute, 5 * time.Minute}
+
+// liveBlock is what the live view keeps of a block
+type liveBlock struct {
+	number   uint64
+	time     uint64
+	txs      int64
+	gasUsed  uint64
+	gasLimit uint64
+}
+
+// WindowStats are the stats of the blocks mined during a span before the latest block
+type WindowStats struct {
+	Span        time.Duration
+	Covered     time.Duration
+	Blocks      int64
+	Txs         int64
+	TPS         float64
+	TxsPerBlock float64
+	GasPerBlock float64
+	// GasUsage is the ratio of the gas limit of the blocks that was used
+	GasUsage  float64
+	BlockTime float64
+}
+
+// Live follows the new heads of a node and reports rolling stats over several windows until it is stopped
+type Live struct {
+	EvrClient *evrclient.Client
+	// Windows are the spans of the rolling stats, DefaultWindows when empty
+	Windows []time.Duration
+	// Refresh is the time between two prints of the view
+	Refresh time.Duration
+	// Out is where the view is printed, os.Stdout when nil
+	Out io.Writer
+
+	blocks  []liveBlock
+	first   *liveBlock
+	last    *liveBlock
+	summary Summary
+	gasUsed uint64
+	gasMax  uint64
+}
+
+// Run prints the view every Refresh until stop is closed, then prints the summary of the blocks seen
+func (l *Live) Run(stop <-chan struct{}) error {
+	var (
+		heads   = make(chan *types.Header)
+		sub     = node.SubscribeNewHeads(l.EvrClient, node.DefaultPollInterval, heads)
+		refresh = l.Refresh
+	)
+	defer sub.Unsubscribe()
+	if refresh <= 0 {
+		refresh = time.Second
+	}
+	ticker := time.NewTicker(refresh)
+	defer ticker.Stop()
+
+	fmt.Fprintln(l.out(), "--- Waiting for new blocks ...")
+	for {
+		select {
+		case head := <-heads:
+			txs, err := l.EvrClient.TransactionCount(context.Background(), head.Hash())
+			if err != nil {
+				fmt.Fprintf(l.out(), "Can not get txs of block %d. Error: %s\n", head.Number.Uint64(), err)
+				continue
+			}
+			l.add(liveBlock{
+				number:   head.Number.Uint64(),
+				time:     head.Time,
+				txs:      int64(txs),
+				gasUsed:  head.GasUsed,
+				gasLimit: head.GasLimit,
+			})
+		case <-ticker.C:
+			fmt.Fprint(l.out(), clearScreen)
+			l.PrintView()
+		case err := <-sub.Err():
+			l.PrintSummary()
+			return err
+		case <-stop:
+			l.PrintSummary()
+			return nil
+		}
+	}
+}
+
+func (l *Live) out() io.Writer {
+	if l.Out == nil {
+		return os.Stdout
+	}
+	return l.Out
+}
+
+func (l *Live) windows() []time.Duration {
+	if len(l.Windows) == 0 {
+		return DefaultWindows
+	}
+	return l.Windows
+}
+
+// add records a new block and forgets the blocks older than the largest window
+func (l *Live) add(b liveBlock) {
+	if l.last != nil && b.number <= l.last.number {
+		// a block of a reorg or sent again after a broken subscription
+		return
+	}
+	if l.first == nil {
+		l.first = &b
+	} else {
+		l.summary.TotalTx += b.txs
+		l.summary.TotalBlock++
+		if b.txs == 0 {
+			l.summary.EmptyBlocks++
+		}
+		l.summary.Duration = b.time - l.first.time
+		l.gasUsed += b.gasUsed
+		l.gasMax += b.gasLimit
+	}
+	l.last = &b
+	l.blocks = append(l.blocks, b)
+
+	var largest time.Duration
+	for _, span := range l.windows() {
+		if span > largest {
+			largest = span
+		}
+	}
+	// keep the block right before the largest window, it is where the window starts
+	keep := 0
+	for i, kept := range l.blocks {
+		if time.Duration(b.time-kept.time)*time.Second < largest {
+			break
+		}
+		keep = i
+	}
+	l.blocks = l.blocks[keep:]
+}
+
+// Window returns the stats of the blocks mined during span before the latest block. The first block seen
+// only marks the start of the time covered, as its txs were sent before the view started.
+func (l *Live) Window(span time.Duration) WindowStats {
+	stats := WindowStats{Span: span}
+	if l.last == nil {
+		return stats
+	}
+	var (
+		since    = l.last.time
+		gasLimit uint64
+		gasUsed  uint64
+	)
+	for i := len(l.blocks) - 1; i > 0; i-- {
+		b, prev := l.blocks[i], l.blocks[i-1]
+		if time.Duration(l.last.time-b.time)*time.Second >= span {
+			break
+		}
+		stats.Blocks++
+		stats.Txs += b.txs
+		gasUsed += b.gasUsed
+		gasLimit += b.gasLimit
+		since = prev.time
+	}
+	stats.Covered = time.Duration(l.last.time-since) * time.Second
+	if stats.Covered > span {
+		stats.Covered = span
+	}
+	if stats.Covered > 0 {
+		stats.TPS = float64(stats.Txs) / stats.Covered.Seconds()
+	}
+	if stats.Blocks != 0 {
+		stats.TxsPerBlock = float64(stats.Txs) / float64(stats.Blocks)
+		stats.GasPerBlock = float64(gasUsed) / float64(stats.Blocks)
+		stats.BlockTime = stats.Covered.Seconds() / float64(stats.Blocks)
+	}
+	if gasLimit != 0 {
+		stats.GasUsage = float64(gasUsed) / float64(gasLimit)
+	}
+	return stats
+}
+
+// PrintView prints the rolling stats of every window
+func (l *Live) PrintView() {
+	out := l.out()
+	fmt.Fprintln(out, "-----------Live Stats----------------")
+	if l.last == nil {
+		fmt.Fprintln(out, "No block yet")
+		return
+	}
+	fmt.Fprintf(out, "Latest block: %d at %s | Txs: %d\n", l.last.number, time.Unix(int64(l.last.time), 0).UTC().String(), l.last.txs)
+	fmt.Fprintf(out, "%-8s %-10s %8s %10s %10s %14s %8s %12s\n",
+		"Window", "Covered", "Blocks", "TPS", "Txs/Block", "Gas/Block", "Gas %", "Block time")
+	for _, span := range l.windows() {
+		w := l.Window(span)
+		fmt.Fprintf(out, "%-8s %-10s %8d %10.2f %10.2f %14.0f %7.2f%% %11.2fs\n",
+			shortDuration(w.Span), shortDuration(w.Covered), w.Blocks, w.TPS, w.TxsPerBlock, w.GasPerBlock, w.GasUsage*100, w.BlockTime)
+	}
+}
+
+// Summary returns the summary of the blocks seen since the view started
+func (l *Live) Summary() Summary {
+	return l.summary
+}
+
+// PrintSummary prints the stats of every block seen since the view started
+func (l *Live) PrintSummary() {
+	out := l.out()
+	fmt.Fprintln(out, "-----------General Stats----------------")
+	if l.first == nil {
+		fmt.Fprintln(out, "No block seen")
+		return
+	}
+	fmt.Fprintf(out, "Blocks: %d to %d\n", l.first.number, l.last.number)
+	fmt.Fprintln(out, "Duration:", l.summary.Duration, "s")
+	fmt.Fprintln(out, "Total Tx:", l.summary.TotalTx)
+	fmt.Fprintln(out, "Total Blocks:", l.summary.TotalBlock)
+	fmt.Fprintln(out, "Total Blocks have 0 Tx:", l.summary.EmptyBlocks)
+	if l.summary.TotalBlock != 0 {
+		fmt.Fprintln(out, "=> AVG Txs/Block:", float64(l.summary.TotalTx)/float64(l.summary.TotalBlock))
+		fmt.Fprintln(out, "=> AVG Gas/Block:", float64(l.gasUsed)/float64(l.summary.TotalBlock))
+	}
+	if l.gasMax != 0 {
+		fmt.Fprintf(out, "=> Gas usage: %.2f%%\n", float64(l.gasUsed)/float64(l.gasMax)*100)
+	}
+	fmt.Fprintln(out, "=> TPS:", l.summary.TPS())
+	fmt.Fprintln(out, "=> AVG BlockTime:", l.summary.BlockTime())
+}
+
+// shortDuration formats d without the zero units time.Duration prints, e.g. 5m instead of 5m0s
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,69 @@
+package tx_metric
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLive_Window(t *testing.T) {
+	lv := &Live{Windows: []time.Duration{10 * time.Second, time.Minute}, Out: &bytes.Buffer{}}
+	assert.Equal(t, WindowStats{Span: 10 * time.Second}, lv.Window(10*time.Second))
+
+	// a block every 2s from t=100 with 10 txs, the first block only starts the view
+	for i := uint64(0); i <= 40; i++ {
+		lv.add(liveBlock{number: i + 1, time: 100 + 2*i, txs: 10, gasUsed: 50, gasLimit: 100})
+	}
+	// a block sent again is ignored
+	lv.add(liveBlock{number: 41, time: 180, txs: 1000})
+
+	w := lv.Window(10 * time.Second)
+	assert.Equal(t, int64(5), w.Blocks)
+	assert.Equal(t, 10*time.Second, w.Covered)
+	assert.Equal(t, float64(5), w.TPS)
+	assert.Equal(t, float64(10), w.TxsPerBlock)
+	assert.Equal(t, float64(2), w.BlockTime)
+	assert.Equal(t, 0.5, w.GasUsage)
+
+	w = lv.Window(time.Minute)
+	assert.Equal(t, int64(30), w.Blocks)
+	assert.Equal(t, time.Minute, w.Covered)
+
+	// a window larger than the time seen covers the time since the first block
+	w = lv.Window(5 * time.Minute)
+	assert.Equal(t, 60*time.Second, w.Covered)
+	assert.Len(t, lv.blocks, 31, "blocks older than the largest window are forgotten")
+
+	summary := lv.Summary()
+	assert.Equal(t, int64(400), summary.TotalTx)
+	assert.Equal(t, int64(40), summary.TotalBlock)
+	assert.Equal(t, uint64(80), summary.Duration)
+	assert.Equal(t, float64(5), summary.TPS())
+}
+
+func TestLive_Print(t *testing.T) {
+	out := &bytes.Buffer{}
+	lv := &Live{Out: out}
+	lv.PrintView()
+	lv.PrintSummary()
+	assert.Contains(t, out.String(), "No block yet")
+
+	out.Reset()
+	lv.add(liveBlock{number: 1, time: 100})
+	lv.add(liveBlock{number: 2, time: 101, txs: 3, gasUsed: 63000, gasLimit: 100000})
+	lv.PrintView()
+	require.Contains(t, out.String(), "5m")
+	assert.Contains(t, out.String(), "Latest block: 2")
+	lv.PrintSummary()
+	assert.Contains(t, out.String(), "Gas usage: 63.00%")
+}
+
+func TestShortDuration(t *testing.T) {
+	assert.Equal(t, "10s", shortDuration(10*time.Second))
+	assert.Equal(t, "5m", shortDuration(5*time.Minute))
+	assert.Equal(t, "1h", shortDuration(time.Hour))
+	assert.Equal(t, "1m30s", shortDuration(90*time.Second))
+}
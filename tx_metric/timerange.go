@@ -0,0 +1,124 @@
+package tx_metric
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ParseTime parses an RFC3339 timestamp, "now" or a time relative to now like "-2h" or "-90m"
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "now" {
+		return now, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, use an RFC3339 timestamp like 2020-04-14T09:20:00Z or a relative time like -2h", value)
+	}
+	return now.Add(d), nil
+}
+
+// head returns the number of the latest block, the latest cached one in offline mode
+func (tm *TxMetric) head() (uint64, error) {
+	if tm.Offline {
+		return tm.Cache.Last()
+	}
+	header, err := tm.EvrClient.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "can not get latest block")
+	}
+	return header.Number.Uint64(), nil
+}
+
+// tail returns the number of the first block, the first cached one in offline mode
+func (tm *TxMetric) tail() (uint64, error) {
+	if tm.Offline {
+		return tm.Cache.First()
+	}
+	return 0, nil
+}
+
+// blockTime returns the time of a block
+func (tm *TxMetric) blockTime(number uint64) (uint64, error) {
+	stats, err := tm.fetchRange(number, number, false)
+	if err != nil {
+		return 0, err
+	}
+	return stats[0].time, nil
+}
+
+// BlockAt returns the first block mined at or after ts, found by binary search over blocks from tail to head.
+// It returns head+1 when every block was mined before ts.
+func (tm *TxMetric) BlockAt(ts uint64, tail, head uint64) (uint64, error) {
+	lo, hi := tail, head+1
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		t, err := tm.blockTime(mid)
+		if err != nil {
+			return 0, err
+		}
+		if t < ts {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo, nil
+}
+
+// FindRange sets the range to the blocks mined from From to To, or for Duration or NumBlock from From
+// when To is not set
+func (tm *TxMetric) FindRange() error {
+	if !tm.To.IsZero() && tm.To.Before(tm.From) {
+		return fmt.Errorf("end of the range %s is before its start %s", tm.To.Format(time.RFC3339), tm.From.Format(time.RFC3339))
+	}
+	fmt.Printf("--- Finding blocks from %s ...\n", tm.From.UTC().Format(time.RFC3339))
+	head, err := tm.head()
+	if err != nil {
+		return err
+	}
+	tail, err := tm.tail()
+	if err != nil {
+		return err
+	}
+	first, err := tm.BlockAt(uint64(tm.From.Unix()), tail, head)
+	if err != nil {
+		return err
+	}
+	if first > head {
+		return fmt.Errorf("no block mined since %s, latest block is %d", tm.From.Format(time.RFC3339), head)
+	}
+	if tm.StartTime, err = tm.blockTime(first); err != nil {
+		return err
+	}
+	// blocks before the first cached one may have been mined after From
+	if first == tail && tail > 0 && tm.StartTime > uint64(tm.From.Unix()) {
+		return errors.Wrapf(ErrNotCached, "blocks before %d, the first cached block, mined after %s", tail, tm.From.Format(time.RFC3339))
+	}
+	tm.StartBlockNumber = first
+	tm.StopTime = uint64(tm.From.Unix()) + uint64(tm.Duration.Seconds())
+	if tm.To.IsZero() {
+		fmt.Printf("Found Block %d at %s\n", first, time.Unix(int64(tm.StartTime), 0).UTC().Format(time.RFC3339))
+		return nil
+	}
+
+	// the last block of the range is the one before the first block mined after To
+	after, err := tm.BlockAt(uint64(tm.To.Unix())+1, first, head)
+	if err != nil {
+		return err
+	}
+	if after <= first {
+		return fmt.Errorf("no block mined from %s to %s", tm.From.Format(time.RFC3339), tm.To.Format(time.RFC3339))
+	}
+	tm.NumBlock = after - 1 - first
+	tm.StopTime = uint64(tm.To.Unix())
+	fmt.Printf("Found Block %d to Block %d\n", first, after-1)
+	return nil
+}
@@ -0,0 +1,110 @@
+package tx_metric
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2020, 4, 14, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value    string
+		expected time.Time
+	}{
+		{value: "2020-04-14T09:20:00Z", expected: time.Date(2020, 4, 14, 9, 20, 0, 0, time.UTC)},
+		{value: "2020-04-14T16:20:00+07:00", expected: time.Date(2020, 4, 14, 9, 20, 0, 0, time.UTC)},
+		{value: "-2h", expected: time.Date(2020, 4, 14, 8, 0, 0, 0, time.UTC)},
+		{value: "-90m", expected: time.Date(2020, 4, 14, 8, 30, 0, 0, time.UTC)},
+		{value: "now", expected: now},
+	}
+	for _, test := range tests {
+		parsed, err := ParseTime(test.value, now)
+		require.NoError(t, err, test.value)
+		assert.True(t, test.expected.Equal(parsed), test.value)
+	}
+	_, err := ParseTime("yesterday", now)
+	assert.Error(t, err)
+}
+
+func TestTxMetric_BlockAt(t *testing.T) {
+	// block i is mined at 100+2i
+	_, tm := newTestNode(t, 9)
+	for ts, expected := range map[uint64]uint64{0: 0, 1: 1, 102: 1, 103: 2, 104: 2, 117: 9, 118: 9, 119: 10} {
+		number, err := tm.BlockAt(ts, 0, 9)
+		require.NoError(t, err)
+		assert.Equal(t, expected, number, "time %d", ts)
+	}
+}
+
+func TestTxMetric_FindRange(t *testing.T) {
+	_, tm := newTestNode(t, 9)
+	tm.From = time.Unix(105, 0)
+	tm.To = time.Unix(112, 0)
+	require.NoError(t, tm.FindRange())
+	assert.Equal(t, uint64(3), tm.StartBlockNumber)
+	assert.Equal(t, uint64(3), tm.NumBlock)
+	assert.Equal(t, uint64(106), tm.StartTime)
+	assert.Equal(t, uint64(112), tm.StopTime)
+
+	require.NoError(t, tm.MetricByBlock())
+	// blocks 3 to 6
+	assert.Equal(t, int64(2+3+4+5), tm.Summary.TotalTx)
+	assert.Equal(t, int64(4), tm.Summary.TotalBlock)
+
+	_, tm = newTestNode(t, 9)
+	tm.From = time.Unix(105, 0)
+	tm.To = time.Unix(112, 0)
+	require.NoError(t, tm.MetricByTime())
+	assert.Equal(t, int64(2+3+4+5), tm.Summary.TotalTx)
+
+	// the range ends after the latest block, at block 9 mined at 118
+	_, tm = newTestNode(t, 9)
+	tm.From = time.Unix(105, 0)
+	tm.To = time.Unix(200, 0)
+	require.NoError(t, tm.MetricByTime())
+	assert.Equal(t, int64(2+3+4+5+6+7+8), tm.Summary.TotalTx)
+	assert.Equal(t, int64(7), tm.Summary.TotalBlock)
+
+	// without To, the range lasts Duration from From, not from the first block after it
+	tm.From = time.Unix(105, 0)
+	tm.To = time.Time{}
+	tm.Duration = 10 * time.Second
+	require.NoError(t, tm.FindRange())
+	assert.Equal(t, uint64(106), tm.StartTime)
+	assert.Equal(t, uint64(115), tm.StopTime)
+
+	// the range is after the latest block
+	tm.From = time.Unix(200, 0)
+	tm.To = time.Time{}
+	assert.Error(t, tm.FindRange())
+	tm.From = time.Unix(105, 0)
+	tm.To = time.Unix(105, 0)
+	assert.Error(t, tm.FindRange())
+	tm.To = time.Unix(104, 0)
+	assert.Error(t, tm.FindRange())
+}
+
+func TestTxMetric_FindRangeOffline(t *testing.T) {
+	cache, done := newTestCache(t)
+	defer done()
+	// the cache holds blocks 4 to 9, mined from 108 to 118
+	blocks, _ := testBlocks(t, 9)
+	for _, bl := range blocks[3:] {
+		require.NoError(t, cache.PutBlock(bl))
+	}
+	tm := &TxMetric{mu: &sync.Mutex{}, Cache: cache, Offline: true}
+	tm.From = time.Unix(109, 0)
+	tm.To = time.Unix(114, 0)
+	require.NoError(t, tm.FindRange())
+	assert.Equal(t, uint64(5), tm.StartBlockNumber)
+	assert.Equal(t, uint64(2), tm.NumBlock)
+
+	// blocks before the cache may be in the range
+	tm.From = time.Unix(100, 0)
+	assert.Equal(t, ErrNotCached, errors.Cause(tm.FindRange()))
+}
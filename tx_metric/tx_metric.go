@@ -1,16 +1,16 @@
 package tx_metric
 
 import (
-	"context"
 	"fmt"
-	"math/big"
+	"math"
 	"sync"
 	"time"
 
-	"github.com/pkg/errors"
-
 	"github.com/Evrynetlabs/evrynet-node/core/types"
 	"github.com/Evrynetlabs/evrynet-node/evrclient"
+	"github.com/Evrynetlabs/evrynet-node/rpc"
+
+	"github.com/evrynet-official/evrynet-tools/lib/results"
 	"github.com/evrynet-official/evrynet-tools/lib/timeutil"
 )
 
@@ -27,11 +27,79 @@ type TxMetric struct {
 	totalTx              int64
 	totalBlock           int64
 	numberOfBlockHasNoTx int64
+	blocks               []blockStat
+	// Summary is the result of the last MetricByTime or MetricByBlock
+	Summary Summary
+	// Breakdown classifies the txs of the blocks from their receipts when it is set
+	Breakdown *Breakdown
+	// Top is the number of classes and senders of the breakdown printed
+	Top int
+	// Cache keeps the blocks, receipts and proposers fetched, so that they are not fetched again when it is set
+	Cache *Cache
+	// Offline reads blocks from Cache only
+	Offline bool
+	// RPC fetches headers and receipts in batch requests when it is set
+	RPC *rpc.Client
+	// Workers is the number of batches of blocks fetched at the same time
+	Workers int
+	// BatchSize is the number of blocks or receipts fetched by a request
+	BatchSize int
+	// From is the time the range starts at when it is set, instead of the first block with txs from StartBlockNumber
+	From time.Time
+	// To is the time the range ends at when it is set, instead of Duration or NumBlock
+	To time.Time
+}
+
+// Summary is the result of a metric over a range of blocks
+type Summary struct {
+	Duration    uint64
+	TotalTx     int64
+	TotalBlock  int64
+	EmptyBlocks int64
+	// Distribution is the spread of block time, txs and gas usage of the range, nil when it is not measured
+	Distribution *Distribution
+}
+
+// TPS returns the number of txs per second of the range
+func (s Summary) TPS() float64 {
+	if s.Duration == 0 {
+		return 0
+	}
+	return float64(s.TotalTx) / float64(s.Duration)
+}
+
+// BlockTime returns the average time between blocks of the range in seconds
+func (s Summary) BlockTime() float64 {
+	if s.TotalBlock == 0 {
+		return 0
+	}
+	return float64(s.Duration) / float64(s.TotalBlock)
+}
+
+// Run returns the record of the summary to save in a result store
+func (s Summary) Run(tool string) *results.Run {
+	run := results.NewRun(tool)
+	run.Metrics[results.TPS] = s.TPS()
+	run.Metrics[results.BlockTime] = s.BlockTime()
+	run.Metrics["total_tx"] = float64(s.TotalTx)
+	run.Metrics["total_block"] = float64(s.TotalBlock)
+	run.Metrics["empty_block"] = float64(s.EmptyBlocks)
+	if s.TotalBlock != 0 {
+		run.Metrics["txs_per_block"] = float64(s.TotalTx) / float64(s.TotalBlock)
+	}
+	if d := s.Distribution; d != nil {
+		run.Metrics["block_time_p50_s"] = d.BlockTime.P50
+		run.Metrics["block_time_p95_s"] = d.BlockTime.P95
+		run.Metrics["block_time_p99_s"] = d.BlockTime.P99
+		run.Metrics["max_block_time_s"] = d.BlockTime.Max
+		run.Metrics["gas_usage_p50"] = d.GasUsage.P50
+	}
+	return run
 }
 
 func (tm *TxMetric) MetricByTime() error {
 	// Update StartBlockNumber & StartTime if reaching a block exists transactions
-	if err := tm.FirstBlockHasTx(); err != nil {
+	if err := tm.findStart(); err != nil {
 		return err
 	}
 	var (
@@ -41,116 +109,184 @@ func (tm *TxMetric) MetricByTime() error {
 		calculateEachMinuteFlag = tm.Duration.Minutes() > 1
 		minuteStats             = make([]int64, int(tm.StopTime-tm.StartTime)/60+1)
 		endTime                 uint64
+		blocks                  []blockStat
+		chunk                   = tm.chunkSize()
+		done                    bool
 	)
 
+	// the range found for To ends at a known block, it may be the latest one
+	last := uint64(math.MaxUint64)
+	if !tm.To.IsZero() {
+		last = tm.StartBlockNumber + tm.NumBlock
+	}
+
 	// Scan every block to sum Tx
 	fmt.Println("--- Starting calculate TPS ...")
-	for i := tm.StartBlockNumber; ; i++ {
-		bl, err := tm.EvrClient.BlockByNumber(context.Background(), big.NewInt(int64(i)))
-		if bl == nil || err != nil {
-			return errors.Errorf("Can not get blocknumber %d. Error: %s", i, err)
+	for first := tm.StartBlockNumber; !done; first += chunk {
+		to := first + chunk - 1
+		if to > last {
+			to = last
 		}
-		if bl.Time() > tm.StopTime {
-			break
+		stats, err := tm.fetchRange(first, to, tm.Breakdown != nil)
+		for i := range stats {
+			stat := &stats[i]
+			if stat.time > tm.StopTime {
+				done = true
+				break
+			}
+			fmt.Printf("Found blocknumber %d at time %s | Txs: %d\n", stat.number, timeutil.TimestampSToTime(stat.time).UTC().String(), stat.txs)
+			if err := tm.addToBreakdown(stat); err != nil {
+				return err
+			}
+			numberOfTx := stat.txs
+			if calculateEachMinuteFlag {
+				fmt.Printf("bl.Time is %d start time is %d index is %d\n", stat.time, tm.StartTime, int(stat.time-tm.StartTime)/60)
+				minuteStats[int(stat.time-tm.StartTime)/60] += numberOfTx
+			}
+
+			totalTx += numberOfTx
+			totalBlock += 1
+			endTime = stat.time
+			blocks = append(blocks, *stat)
+			if numberOfTx == 0 {
+				numberOfBlockHasNoTx++
+			}
 		}
-		fmt.Printf("Found blocknumber %d at time %s | Txs: %d\n", i, timeutil.TimestampSToTime(bl.Time()).UTC().String(), bl.Transactions().Len())
-		numberOfTx := int64(bl.Transactions().Len())
-		if calculateEachMinuteFlag {
-			fmt.Printf("bl.Time is %d start time is %d index is %d\n", bl.Time(), tm.StartTime, int(bl.Time()-tm.StartTime)/60)
-			minuteStats[int(bl.Time()-tm.StartTime)/60] += numberOfTx
+		// the blocks after StopTime may not be mined yet
+		if !done && err != nil {
+			return err
 		}
-
-		totalTx += numberOfTx
-		totalBlock += 1
-		endTime = bl.Time()
-		if bl.Transactions().Len() == 0 {
-			numberOfBlockHasNoTx++
+		if to == last {
+			done = true
 		}
 	}
 
+	tm.blocks = blocks
 	tm.Report(endTime, totalBlock, totalTx, numberOfBlockHasNoTx, minuteStats)
 
 	return nil
 }
 
 func (tm *TxMetric) UpdateMetric(bl *types.Block) {
+	tm.updateMetric(newBlockStat(bl))
+}
+
+func (tm *TxMetric) updateMetric(stat blockStat) {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
-	numberOfTx := int64(bl.Transactions().Len())
+	numberOfTx := stat.txs
 
-	index := int(bl.Time()-tm.StartTime) / 60
+	index := int(stat.time-tm.StartTime) / 60
 	for index >= len(tm.minuteStats) {
 		tm.minuteStats = append(tm.minuteStats, 0)
 	}
-	fmt.Printf("index is %d, len minutesStats is %d", index, len(tm.minuteStats))
 	tm.minuteStats[index] += numberOfTx
 
 	tm.totalTx += numberOfTx
 	tm.totalBlock += 1
-	if tm.endTime < bl.Time() {
-		tm.endTime = bl.Time()
+	tm.blocks = append(tm.blocks, stat)
+	if tm.endTime < stat.time {
+		tm.endTime = stat.time
 	}
-	if bl.Transactions().Len() == 0 {
+	if numberOfTx == 0 {
 		tm.numberOfBlockHasNoTx++
 	}
 }
 
 func (tm *TxMetric) GetBlock(i int64) error {
-	var (
-		bl  *types.Block
-		err error
-	)
 	fmt.Printf("Getting block %d\n", i)
-	for attempt := 0; attempt <= 10; attempt++ {
-		bl, err = tm.EvrClient.BlockByNumber(context.Background(), big.NewInt(i))
-		if bl == nil || err != nil {
-			fmt.Printf("Can not get block number %d. Error: %s, attempt: %d\n", i, err, attempt)
-		} else {
-			fmt.Printf("Found blocknumber %d at time %s | Txs: %d\n", i, timeutil.TimestampSToTime(bl.Time()).UTC().String(), bl.Transactions().Len())
-			tm.UpdateMetric(bl)
-			return nil
-		}
+	stats, err := tm.fetchRange(uint64(i), uint64(i), tm.Breakdown != nil)
+	if err != nil {
+		return err
 	}
-	return err
+	return tm.addStat(&stats[0])
 }
+
+// addStat adds a fetched block to the metric
+func (tm *TxMetric) addStat(stat *blockStat) error {
+	fmt.Printf("Found blocknumber %d at time %s | Txs: %d\n", stat.number, timeutil.TimestampSToTime(stat.time).UTC().String(), stat.txs)
+	if err := tm.addToBreakdown(stat); err != nil {
+		return err
+	}
+	tm.updateMetric(*stat)
+	return nil
+}
+
+// addToBreakdown classifies the txs of a block when the breakdown is set, and releases the block and its receipts
+func (tm *TxMetric) addToBreakdown(stat *blockStat) error {
+	bl, receipts := stat.block, stat.receipts
+	stat.block, stat.receipts = nil, nil
+	if tm.Breakdown == nil {
+		return nil
+	}
+	return tm.Breakdown.AddBlock(bl, receipts)
+}
+
 func (tm *TxMetric) MetricByBlock() error {
-	if err := tm.FirstBlockHasTx(); err != nil {
+	if err := tm.findStart(); err != nil {
 		return err
 	}
 	var (
-		wg    = &sync.WaitGroup{}
-		batch = 10
-		blN   = tm.StartBlockNumber
+		last  = tm.StartBlockNumber + tm.NumBlock
+		chunk = tm.chunkSize()
 	)
 
 	// Scan every block to sum Tx
-	fmt.Printf("--- Starting calculate TPS from Block %d to Block %d ---  ...\n", tm.StartBlockNumber, tm.StartBlockNumber+tm.NumBlock)
-	for {
-		for i := 0; i < batch; i++ {
-			wg.Add(1)
-			go func(blockNum int64) {
-				defer wg.Done()
-				if err := tm.GetBlock(blockNum); err != nil {
-					panic(err)
-				}
-			}(int64(blN))
-			blN++
-			if blN > tm.StartBlockNumber+tm.NumBlock {
-				break
-			}
+	fmt.Printf("--- Starting calculate TPS from Block %d to Block %d ---  ...\n", tm.StartBlockNumber, last)
+	for first := tm.StartBlockNumber; first <= last; first += chunk {
+		to := first + chunk - 1
+		if to > last {
+			to = last
+		}
+		stats, err := tm.fetchRange(first, to, tm.Breakdown != nil)
+		if err != nil {
+			return err
 		}
-		wg.Wait()
-		fmt.Printf("Done 1 batch from block %d to block %d\n", blN-uint64(batch), blN)
-		if blN > tm.StartBlockNumber+tm.NumBlock {
-			break
+		for i := range stats {
+			if err := tm.addStat(&stats[i]); err != nil {
+				return err
+			}
 		}
+		fmt.Printf("Done 1 batch from block %d to block %d\n", first, to)
 	}
 	tm.Report(tm.endTime, tm.totalBlock, tm.totalTx, tm.numberOfBlockHasNoTx, tm.minuteStats)
 
 	return nil
 }
 
+// SummaryOf returns the summary of the blocks from start to end, measured from the time of start
+func SummaryOf(client *evrclient.Client, start, end uint64) (Summary, error) {
+	var summary Summary
+	if end < start {
+		return summary, fmt.Errorf("end block %d is before start block %d", end, start)
+	}
+	tm := &TxMetric{EvrClient: client}
+	blocks, err := tm.fetchRange(start, end, false)
+	if err != nil {
+		return summary, err
+	}
+	for _, bl := range blocks {
+		summary.TotalTx += bl.txs
+		summary.TotalBlock++
+		if bl.txs == 0 {
+			summary.EmptyBlocks++
+		}
+		summary.Duration = bl.time - blocks[0].time
+	}
+	summary.Distribution = newDistribution(blocks)
+	return summary, nil
+}
+
 func (tm *TxMetric) Report(endTime uint64, totalBlock, totalTx, numberOfBlockHasNoTx int64, minuteStats []int64) {
+	tm.Summary = Summary{
+		Duration:    endTime - tm.StartTime,
+		TotalTx:     totalTx,
+		TotalBlock:  totalBlock,
+		EmptyBlocks: numberOfBlockHasNoTx,
+	}
+	if len(tm.blocks) != 0 {
+		tm.Summary.Distribution = newDistribution(tm.blocks)
+	}
 	fmt.Println("-----------General Stats----------------")
 	fmt.Println("Duration:", endTime-tm.StartTime, "s")
 	fmt.Println("Total Tx:", totalTx)
@@ -166,23 +302,39 @@ func (tm *TxMetric) Report(endTime uint64, totalBlock, totalTx, numberOfBlockHas
 			fmt.Printf("Txs at minute %d: %d, avg TPS in this minute %.4f\n", index+1, txs, float64(txs)/60.0)
 		}
 	}
+	if tm.Summary.Distribution != nil {
+		tm.Summary.Distribution.Print()
+	}
+	if tm.Breakdown != nil {
+		tm.Breakdown.Print(tm.Top)
+	}
+}
+
+// findStart sets the range from From when it is set, or from the first block with txs from StartBlockNumber
+func (tm *TxMetric) findStart() error {
+	if !tm.From.IsZero() {
+		return tm.FindRange()
+	}
+	return tm.FirstBlockHasTx()
 }
 
 func (tm *TxMetric) FirstBlockHasTx() error {
 	// Update StartBlockNumber & StartTime if reaching a block exists transactions
 	fmt.Println("--- Finding block has Tx ...")
-	for ; ; tm.StartBlockNumber++ {
-		bl, err := tm.EvrClient.BlockByNumber(context.Background(), big.NewInt(int64(tm.StartBlockNumber)))
+	chunk := tm.chunkSize()
+	for ; ; tm.StartBlockNumber += chunk {
+		stats, err := tm.fetchRange(tm.StartBlockNumber, tm.StartBlockNumber+chunk-1, false)
+		for _, stat := range stats {
+			fmt.Printf("Found Block %d | Txs: %d\n", stat.number, stat.txs)
+			if stat.txs > 0 {
+				tm.StartBlockNumber = stat.number
+				tm.StartTime = stat.time
+				tm.StopTime = tm.StartTime + uint64(tm.Duration.Seconds())
+				return nil
+			}
+		}
 		if err != nil {
 			return err
 		}
-		fmt.Printf("Found Block %d | Txs: %d\n", tm.StartBlockNumber, bl.Transactions().Len())
-
-		if bl.Transactions().Len() > 0 {
-			tm.StartTime = bl.Time()
-			tm.StopTime = tm.StartTime + uint64(tm.Duration.Seconds())
-			return nil
-		}
 	}
-	return nil
 }
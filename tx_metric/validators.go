@@ -0,0 +1,267 @@
+package tx_metric
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/Evrynetlabs/evrynet-node"
+	"github.com/Evrynetlabs/evrynet-node/accounts/abi/bind"
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/common/hexutil"
+	stakingContracts "github.com/Evrynetlabs/evrynet-node/consensus/staking_contracts"
+	"github.com/Evrynetlabs/evrynet-node/evrclient"
+	"github.com/Evrynetlabs/evrynet-node/rpc"
+)
+
+// ProposerStats are the blocks of a range proposed by a validator
+type ProposerStats struct {
+	Address common.Address
+	Blocks  int
+	Txs     int64
+	// Candidate is whether the proposer is in the candidate set of the staking contract
+	Candidate bool
+	// followed is the number of its blocks followed by another block of the range, timeAfter the sum of
+	// the times to that block
+	followed  int
+	timeAfter uint64
+}
+
+// AvgTxs returns the average number of txs of the blocks of the proposer
+func (p *ProposerStats) AvgTxs() float64 {
+	if p.Blocks == 0 {
+		return 0
+	}
+	return float64(p.Txs) / float64(p.Blocks)
+}
+
+// AvgBlockTimeAfter returns the average time in seconds from a block of the proposer to the next block
+func (p *ProposerStats) AvgBlockTimeAfter() float64 {
+	if p.followed == 0 {
+		return 0
+	}
+	return float64(p.timeAfter) / float64(p.followed)
+}
+
+// Validators is the share of the blocks of a range proposed by every validator, compared with the candidate
+// set of the staking contract
+type Validators struct {
+	First  uint64
+	Last   uint64
+	Blocks int
+	// Proposers are sorted by number of blocks, most first
+	Proposers []*ProposerStats
+	// Candidates is the candidate set of the staking contract, nil when it is not known
+	Candidates []common.Address
+	// Missing are the candidates that proposed no block of the range
+	Missing []common.Address
+}
+
+// newValidators attributes blocks, in order and without gap, to their proposers
+func newValidators(blocks []blockStat, proposers []common.Address, candidates []common.Address) *Validators {
+	var (
+		v = &Validators{Blocks: len(blocks), Candidates: candidates}
+		// a proposer is not a candidate when the candidate set is not known
+		isCandidate = make(map[common.Address]bool)
+		byAddress   = make(map[common.Address]*ProposerStats)
+	)
+	for _, candidate := range candidates {
+		isCandidate[candidate] = true
+	}
+	for i, bl := range blocks {
+		p, ok := byAddress[proposers[i]]
+		if !ok {
+			p = &ProposerStats{Address: proposers[i], Candidate: isCandidate[proposers[i]]}
+			byAddress[proposers[i]] = p
+			v.Proposers = append(v.Proposers, p)
+		}
+		p.Blocks++
+		p.Txs += bl.txs
+		if i+1 < len(blocks) && blocks[i+1].time >= bl.time {
+			p.followed++
+			p.timeAfter += blocks[i+1].time - bl.time
+		}
+	}
+	if len(blocks) != 0 {
+		v.First, v.Last = blocks[0].number, blocks[len(blocks)-1].number
+	}
+	sort.SliceStable(v.Proposers, func(i, j int) bool { return v.Proposers[i].Blocks > v.Proposers[j].Blocks })
+	for _, candidate := range candidates {
+		if _, ok := byAddress[candidate]; !ok {
+			v.Missing = append(v.Missing, candidate)
+		}
+	}
+	return v
+}
+
+// Validators returns the proposers of the blocks from first to last, compared with candidates
+func (tm *TxMetric) Validators(first, last uint64, candidates []common.Address) (*Validators, error) {
+	if last < first {
+		return nil, fmt.Errorf("invalid block range %d - %d", first, last)
+	}
+	var (
+		blocks    []blockStat
+		proposers []common.Address
+	)
+	fmt.Printf("--- Finding proposers of Block %d to Block %d ...\n", first, last)
+	for from := first; from <= last; from += tm.chunkSize() {
+		to := from + tm.chunkSize() - 1
+		if to > last {
+			to = last
+		}
+		stats, err := tm.fetchRange(from, to, false)
+		if err != nil {
+			return nil, err
+		}
+		chunk, err := tm.proposers(from, to)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, stats...)
+		proposers = append(proposers, chunk...)
+		fmt.Printf("Done 1 batch from block %d to block %d\n", from, to)
+	}
+	return newValidators(blocks, proposers, candidates), nil
+}
+
+// proposers returns the proposers of the blocks from first to last, from the cache or in batch requests to the
+// node when they are not cached. The proposer of a block without one, like the genesis block, is the zero address.
+func (tm *TxMetric) proposers(first, last uint64) ([]common.Address, error) {
+	var (
+		proposers = make([]common.Address, last-first+1)
+		missing   []uint64
+	)
+	for number := first; number <= last; number++ {
+		if tm.Cache != nil {
+			proposer, err := tm.Cache.Proposer(number)
+			if err != nil {
+				return nil, err
+			}
+			if proposer != nil {
+				proposers[number-first] = *proposer
+				continue
+			}
+			if tm.Offline {
+				return nil, errors.Wrapf(ErrNotCached, "proposer of block %d", number)
+			}
+		}
+		missing = append(missing, number)
+	}
+
+	for start := 0; start < len(missing); start += tm.batchSize() {
+		end := start + tm.batchSize()
+		if end > len(missing) {
+			end = len(missing)
+		}
+		details, err := tm.fetchSigners(missing[start:end])
+		if err != nil {
+			return nil, err
+		}
+		for i, number := range missing[start:end] {
+			if details[i].BlockProposer == nil {
+				continue
+			}
+			proposers[number-first] = *details[i].BlockProposer
+			if tm.Cache != nil {
+				if err := tm.Cache.PutProposer(number, *details[i].BlockProposer); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return proposers, nil
+}
+
+// fetchSigners returns the extra data details of blocks from the node, in a batch request when it can
+func (tm *TxMetric) fetchSigners(numbers []uint64) ([]*evrclient.ExtraDataDetails, error) {
+	details := make([]*evrclient.ExtraDataDetails, len(numbers))
+	batch := make([]rpc.BatchElem, len(numbers))
+	for i, number := range numbers {
+		batch[i] = rpc.BatchElem{
+			Method: "eth_getBlockSignerByNumber",
+			Args:   []interface{}{hexutil.EncodeUint64(number)},
+			Result: &details[i],
+		}
+	}
+	err := retry(fmt.Sprintf("proposers of %d blocks", len(numbers)), func() error {
+		if tm.RPC == nil {
+			for i, number := range numbers {
+				proposer, err := proposerOf(tm.EvrClient, number)
+				if err != nil {
+					return err
+				}
+				details[i] = &evrclient.ExtraDataDetails{BlockProposer: proposer}
+			}
+			return nil
+		}
+		if err := tm.RPC.BatchCallContext(context.Background(), batch); err != nil {
+			return err
+		}
+		for _, elem := range batch {
+			if elem.Error != nil {
+				return elem.Error
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, number := range numbers {
+		if details[i] == nil {
+			return nil, errors.Wrapf(evrynet.NotFound, "proposer of block %d", number)
+		}
+	}
+	return details, nil
+}
+
+// Candidates returns the candidate set of the staking contract at address
+func Candidates(client *evrclient.Client, address common.Address) ([]common.Address, error) {
+	contract, err := stakingContracts.NewStakingContracts(address, client)
+	if err != nil {
+		return nil, err
+	}
+	response, err := contract.GetListCandidates(&bind.CallOpts{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "can not get candidates of staking contract %s", address.Hex())
+	}
+	return response.Candidates, nil
+}
+
+// Print prints the proposers on console view
+func (v *Validators) Print() {
+	fmt.Println("-----------Validators Stats----------------")
+	fmt.Printf("Block %d to Block %d: %d blocks, %d proposers\n", v.First, v.Last, v.Blocks, len(v.Proposers))
+	if v.Candidates != nil {
+		fmt.Printf("Candidates: %d, expected share of each: %.2f%%\n", len(v.Candidates), ratio(1, int64(len(v.Candidates))))
+	}
+	fmt.Printf("%-60s %8s %8s %14s %18s\n", "Proposer", "Blocks", "Share", "AVG Txs/Block", "AVG BlockTime (s)")
+	for _, p := range v.Proposers {
+		name := p.Address.Hex()
+		switch {
+		case p.Address == (common.Address{}):
+			name = "unknown"
+		case v.Candidates != nil && !p.Candidate:
+			name += " (not a candidate)"
+		}
+		fmt.Printf("%-60s %8d %7.2f%% %14.2f %18.2f\n", name, p.Blocks, ratio(int64(p.Blocks), int64(v.Blocks)), p.AvgTxs(), p.AvgBlockTimeAfter())
+	}
+	if len(v.Missing) != 0 {
+		fmt.Printf("Candidates without block: %d\n", len(v.Missing))
+		for _, candidate := range v.Missing {
+			fmt.Println(candidate.Hex())
+		}
+	}
+}
+
+// proposerOf returns the proposer of a block, read from the extra data of its header
+func proposerOf(client *evrclient.Client, number uint64) (*common.Address, error) {
+	details, err := client.GetBlockSignerByNumber(context.Background(), new(big.Int).SetUint64(number))
+	if err != nil {
+		return nil, errors.Wrapf(err, "can not get proposer of block %d", number)
+	}
+	return details.BlockProposer, nil
+}
@@ -0,0 +1,85 @@
+package tx_metric
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Evrynetlabs/evrynet-node/common"
+	"github.com/Evrynetlabs/evrynet-node/rpc"
+)
+
+func (n *testNode) GetBlockSignerByNumber(number rpc.BlockNumber) (map[string]interface{}, error) {
+	if err := n.fail(); err != nil {
+		return nil, err
+	}
+	if number < 0 || int(number) >= len(n.blocks) {
+		return nil, nil
+	}
+	details := map[string]interface{}{"rawData": "0x"}
+	if number > 0 {
+		details["blockProposer"] = n.proposers[int(number-1)%len(n.proposers)]
+	}
+	return details, nil
+}
+
+func TestNewValidators(t *testing.T) {
+	var (
+		a      = common.HexToAddress("0x0a")
+		b      = common.HexToAddress("0x0b")
+		c      = common.HexToAddress("0x0c")
+		d      = common.HexToAddress("0x0d")
+		blocks = []blockStat{
+			{number: 10, time: 100, txs: 4},
+			{number: 11, time: 101, txs: 2},
+			{number: 12, time: 106, txs: 0},
+			{number: 13, time: 107, txs: 6},
+		}
+	)
+	v := newValidators(blocks, []common.Address{a, b, a, d}, []common.Address{a, b, c})
+	assert.Equal(t, uint64(10), v.First)
+	assert.Equal(t, uint64(13), v.Last)
+	assert.Equal(t, 4, v.Blocks)
+	require.Len(t, v.Proposers, 3)
+
+	assert.Equal(t, a, v.Proposers[0].Address)
+	assert.Equal(t, 2, v.Proposers[0].Blocks)
+	assert.Equal(t, 2.0, v.Proposers[0].AvgTxs())
+	// 1s after block 10 and 1s after block 12
+	assert.Equal(t, 1.0, v.Proposers[0].AvgBlockTimeAfter())
+	assert.True(t, v.Proposers[0].Candidate)
+
+	assert.Equal(t, b, v.Proposers[1].Address)
+	assert.Equal(t, 5.0, v.Proposers[1].AvgBlockTimeAfter())
+
+	// the last block is followed by no block of the range
+	assert.Equal(t, d, v.Proposers[2].Address)
+	assert.False(t, v.Proposers[2].Candidate)
+	assert.Equal(t, 0.0, v.Proposers[2].AvgBlockTimeAfter())
+
+	assert.Equal(t, []common.Address{c}, v.Missing)
+	v.Print()
+}
+
+func TestTxMetric_Validators(t *testing.T) {
+	node, tm := newTestNode(t, 7)
+	a, b := common.HexToAddress("0x0a"), common.HexToAddress("0x0b")
+	node.proposers = []common.Address{a, b}
+
+	v, err := tm.Validators(0, 7, []common.Address{a, b})
+	require.NoError(t, err)
+	assert.Equal(t, 8, v.Blocks)
+	require.Len(t, v.Proposers, 3)
+	// blocks 1, 3, 5 and 7 are proposed by a
+	assert.Equal(t, a, v.Proposers[0].Address)
+	assert.Equal(t, 4, v.Proposers[0].Blocks)
+	assert.Equal(t, int64(0+2+4+6), v.Proposers[0].Txs)
+	assert.Equal(t, 3, v.Proposers[1].Blocks)
+	// the genesis block has no proposer
+	assert.Equal(t, common.Address{}, v.Proposers[2].Address)
+	assert.Empty(t, v.Missing)
+
+	_, err = tm.Validators(5, 9, nil)
+	assert.True(t, isMissing(err))
+}